@@ -0,0 +1,89 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+)
+
+// AuthTypeOptions are the capabilities of the datasource which determine the available authentication types.
+type AuthTypeOptions struct {
+	// Whether the datasource supports user identity authentication
+	UserIdentitySupported bool
+}
+
+// AuthTypeAvailability tells whether the authentication type can be used by the datasource in this Grafana instance.
+type AuthTypeAvailability struct {
+	AuthType  string
+	Available bool
+	// Reason why the authentication type isn't available
+	Reason string
+}
+
+// AvailableAuthTypes returns the availability of each built-in authentication type for the given Grafana
+// settings and datasource capabilities. The Azure token provider accepts only the credentials of
+// the available authentication types, except the key credentials (see IsKeyCredentials) which don't
+// need an access token.
+func AvailableAuthTypes(settings *azsettings.AzureSettings, options AuthTypeOptions) ([]AuthTypeAvailability, error) {
+	if settings == nil {
+		return nil, fmt.Errorf("parameter 'settings' cannot be nil")
+	}
+
+	result := make([]AuthTypeAvailability, 0, len(builtInDescriptors))
+	for _, descriptor := range builtInDescriptors {
+		availability := AuthTypeAvailability{
+			AuthType:  descriptor.AuthType,
+			Available: true,
+		}
+		if err := getAuthTypeUnavailableError(settings, descriptor.AuthType, options); err != nil {
+			availability.Available = false
+			availability.Reason = err.Error()
+		}
+		result = append(result, availability)
+	}
+	return result, nil
+}
+
+// CheckAuthTypeAvailable returns an error with the reason if the authentication type cannot be used
+// by the datasource, custom authentication types are always available.
+func CheckAuthTypeAvailable(settings *azsettings.AzureSettings, authType string, options AuthTypeOptions) error {
+	if settings == nil {
+		return fmt.Errorf("parameter 'settings' cannot be nil")
+	}
+	return getAuthTypeUnavailableError(settings, authType, options)
+}
+
+func getAuthTypeUnavailableError(settings *azsettings.AzureSettings, authType string, options AuthTypeOptions) error {
+	switch authType {
+	case AzureAuthCurrentUserIdentity:
+		if !options.UserIdentitySupported {
+			return fmt.Errorf("user identity authentication is not supported by this datasource")
+		}
+		if !settings.UserIdentityEnabled {
+			return fmt.Errorf("user identity authentication is not enabled in Grafana config")
+		}
+	case AzureAuthClientSecretObo:
+		if !options.UserIdentitySupported {
+			return fmt.Errorf("user identity authentication is not supported by this datasource")
+		}
+	case AzureAuthManagedIdentity:
+		if !settings.ManagedIdentityEnabled {
+			return fmt.Errorf("managed identity authentication is not enabled in Grafana config")
+		}
+	case AzureAuthWorkloadIdentity:
+		if !settings.WorkloadIdentityEnabled {
+			return fmt.Errorf("workload identity authentication is not enabled in Grafana config")
+		}
+	case AzureAuthEntraPasswordCredentials:
+		if !settings.AzureEntraPasswordCredentialsEnabled {
+			return fmt.Errorf("Entra password authentication is not enabled in Grafana config")
+		}
+	case AzureAuthClientAssertion:
+		// Client assertion credentials with a callback can only be created programmatically and are always
+		// available, the datasource configuration can only refer to an assertion file
+		if !settings.ClientAssertionCredentialsEnabled {
+			return fmt.Errorf("client assertion authentication is not enabled in Grafana config")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getAvailability(t *testing.T, availableAuthTypes []AuthTypeAvailability, authType string) AuthTypeAvailability {
+	t.Helper()
+	for _, availability := range availableAuthTypes {
+		if availability.AuthType == authType {
+			return availability
+		}
+	}
+	require.Failf(t, "authentication type not found", "authentication type '%s' not returned", authType)
+	return AuthTypeAvailability{}
+}
+
+func TestAvailableAuthTypes(t *testing.T) {
+	t.Run("should return all built-in authentication types", func(t *testing.T) {
+		availableAuthTypes, err := AvailableAuthTypes(&azsettings.AzureSettings{}, AuthTypeOptions{})
+		require.NoError(t, err)
+
+		require.Len(t, availableAuthTypes, len(allAuthTypes))
+		for i, authType := range allAuthTypes {
+			assert.Equal(t, authType, availableAuthTypes[i].AuthType)
+		}
+	})
+
+	t.Run("should return only authentication types which don't require Grafana config if nothing enabled", func(t *testing.T) {
+		availableAuthTypes, err := AvailableAuthTypes(&azsettings.AzureSettings{}, AuthTypeOptions{})
+		require.NoError(t, err)
+
+		var available []string
+		for _, availability := range availableAuthTypes {
+			if availability.Available {
+				available = append(available, availability.AuthType)
+				assert.Empty(t, availability.Reason)
+			} else {
+				assert.NotEmpty(t, availability.Reason)
+			}
+		}
+		assert.Equal(t, []string{AzureAuthClientSecret, AzureAuthClientCertificate, AzureAuthApiKey, AzureAuthStorageSharedKey, AzureAuthStorageSas, AzureAuthChained}, available)
+	})
+
+	t.Run("should return all authentication types if everything enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ManagedIdentityEnabled:               true,
+			WorkloadIdentityEnabled:              true,
+			UserIdentityEnabled:                  true,
+			AzureEntraPasswordCredentialsEnabled: true,
+			ClientAssertionCredentialsEnabled:    true,
+		}
+
+		availableAuthTypes, err := AvailableAuthTypes(settings, AuthTypeOptions{UserIdentitySupported: true})
+		require.NoError(t, err)
+
+		for _, availability := range availableAuthTypes {
+			assert.True(t, availability.Available, availability.AuthType)
+		}
+	})
+
+	t.Run("should return reason when authentication type not enabled in Grafana config", func(t *testing.T) {
+		availableAuthTypes, err := AvailableAuthTypes(&azsettings.AzureSettings{}, AuthTypeOptions{UserIdentitySupported: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, "managed identity authentication is not enabled in Grafana config", getAvailability(t, availableAuthTypes, AzureAuthManagedIdentity).Reason)
+		assert.Equal(t, "workload identity authentication is not enabled in Grafana config", getAvailability(t, availableAuthTypes, AzureAuthWorkloadIdentity).Reason)
+		assert.Equal(t, "user identity authentication is not enabled in Grafana config", getAvailability(t, availableAuthTypes, AzureAuthCurrentUserIdentity).Reason)
+		assert.Equal(t, "Entra password authentication is not enabled in Grafana config", getAvailability(t, availableAuthTypes, AzureAuthEntraPasswordCredentials).Reason)
+		assert.Equal(t, "client assertion authentication is not enabled in Grafana config", getAvailability(t, availableAuthTypes, AzureAuthClientAssertion).Reason)
+		assert.True(t, getAvailability(t, availableAuthTypes, AzureAuthClientSecretObo).Available)
+	})
+
+	t.Run("should return reason when user identity not supported by datasource", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			UserIdentityEnabled: true,
+		}
+
+		availableAuthTypes, err := AvailableAuthTypes(settings, AuthTypeOptions{UserIdentitySupported: false})
+		require.NoError(t, err)
+
+		for _, authType := range []string{AzureAuthCurrentUserIdentity, AzureAuthClientSecretObo} {
+			availability := getAvailability(t, availableAuthTypes, authType)
+			assert.False(t, availability.Available)
+			assert.Equal(t, "user identity authentication is not supported by this datasource", availability.Reason)
+		}
+	})
+
+	t.Run("should return error if settings not set", func(t *testing.T) {
+		_, err := AvailableAuthTypes(nil, AuthTypeOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckAuthTypeAvailable(t *testing.T) {
+	t.Run("should return error with reason if authentication type not available", func(t *testing.T) {
+		err := CheckAuthTypeAvailable(&azsettings.AzureSettings{}, AzureAuthManagedIdentity, AuthTypeOptions{})
+		assert.EqualError(t, err, "managed identity authentication is not enabled in Grafana config")
+	})
+
+	t.Run("should not return error for custom authentication type", func(t *testing.T) {
+		err := CheckAuthTypeAvailable(&azsettings.AzureSettings{}, "custom", AuthTypeOptions{})
+		assert.NoError(t, err)
+	})
+}
@@ -2,208 +2,288 @@ package azcredentials
 
 import (
 	"fmt"
-
-	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
+	"strings"
 )
 
 func FromDatasourceData(data map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
-	if credentialsObj, err := maputil.GetMapOptional(data, "azureCredentials"); err != nil {
-		return nil, err
-	} else if credentialsObj == nil {
-		return nil, nil
+	credentials, errs := parseDatasourceData(data, secureData)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return credentials, nil
+}
+
+// parseDatasourceData reads the credentials collecting all the problems found in the datasource data
+// instead of stopping at the first one, the returned credentials are incomplete if there are any errors
+func parseDatasourceData(data map[string]interface{}, secureData map[string]string) (AzureCredentials, []*FieldError) {
+	reader := newDataReader(data, secureData)
+	if credentialsObj := reader.getMapOptional(credentialsPath); credentialsObj == nil {
+		return nil, reader.errs.list
 	} else {
-		return getFromCredentialsObject(credentialsObj, secureData)
+		return getFromCredentialsObject(credentialsObj), reader.errs.list
 	}
 }
 
-func getFromCredentialsObject(credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
-	authType, err := maputil.GetString(credentialsObj, "authType")
+func getFromCredentialsObject(credentialsObj *dataReader) AzureCredentials {
+	authType, err := credentialsObj.lookupString("authType")
 	if err != nil {
-		return nil, err
+		credentialsObj.addFieldError("authType", err.Error())
+		return nil
 	}
 
-	switch authType {
-	case AzureAuthCurrentUserIdentity:
-		serviceCredentialsEnabled, err := maputil.GetBoolOptional(credentialsObj, "serviceCredentialsEnabled")
+	if parser, ok := getCustomParser(authType); ok {
+		credentials, err := parser(credentialsObj.obj, credentialsObj.getSecureData())
 		if err != nil {
-			return nil, err
+			credentialsObj.addError(err)
+			return nil
 		}
+		return credentials
+	}
+
+	switch authType {
+	case AzureAuthCurrentUserIdentity:
+		serviceCredentialsEnabled := credentialsObj.getBoolField(authType, "serviceCredentialsEnabled")
 
 		var fallbackCredentials AzureCredentials
 		if serviceCredentialsEnabled {
-			creds, err := maputil.GetMapOptional(credentialsObj, "serviceCredentials")
-			if err != nil {
-				return nil, err
-			}
-			fallbackCredentials, err = getFromCredentialsObject(creds, secureData)
-			if err != nil {
-				return nil, err
+			if creds := credentialsObj.getMapOptional("serviceCredentials"); creds != nil {
+				fallbackCredentials = getFromCredentialsObject(creds)
+			} else {
+				// Missing service credentials object has same result as empty object
+				credentialsObj.addFieldError("serviceCredentials.authType", "the field 'authType' should be set")
 			}
 		}
 		credentials := &AadCurrentUserCredentials{
 			ServiceCredentialsEnabled: serviceCredentialsEnabled,
 			ServiceCredentials:        fallbackCredentials,
 		}
-		return credentials, nil
+		return credentials
 
 	case AzureAuthManagedIdentity:
-		credentials := &AzureManagedIdentityCredentials{}
-		return credentials, nil
-
-	case AzureAuthWorkloadIdentity:
-		credentials := &AzureWorkloadIdentityCredentials{}
-		tenantId, err := maputil.GetStringOptional(credentialsObj, "tenantId")
-		if err != nil {
-			return nil, err
-		}
-		if tenantId != "" {
-			credentials.TenantId = tenantId
+		credentials := &AzureManagedIdentityCredentials{
+			ClientId:   credentialsObj.getField(authType, "clientId"),
+			ObjectId:   credentialsObj.getField(authType, "objectId"),
+			ResourceId: credentialsObj.getField(authType, "resourceId"),
 		}
-		clientId, err := maputil.GetStringOptional(credentialsObj, "clientId")
-		if err != nil {
-			return nil, err
-		}
-		if clientId != "" {
-			credentials.ClientId = clientId
+		if key, ok := getManagedIdentityConflict(credentials); !ok {
+			credentialsObj.addFieldError(key, managedIdentityConflictMessage)
 		}
+		return credentials
 
-		return credentials, nil
+	case AzureAuthWorkloadIdentity:
+		credentials := &AzureWorkloadIdentityCredentials{
+			AzureCloud: credentialsObj.getField(authType, "azureCloud"),
+			TenantId:   credentialsObj.getField(authType, "tenantId"),
+			ClientId:   credentialsObj.getField(authType, "clientId"),
+		}
+		return credentials
 
 	case AzureAuthClientSecret:
-		cloud, err := maputil.GetString(credentialsObj, "azureCloud")
-		if err != nil {
-			return nil, err
-		}
-		tenantId, err := maputil.GetString(credentialsObj, "tenantId")
-		if err != nil {
-			return nil, err
+		credentials := &AzureClientSecretCredentials{
+			AzureCloud:            credentialsObj.getField(authType, "azureCloud"),
+			TenantId:              credentialsObj.getField(authType, "tenantId"),
+			ClientId:              credentialsObj.getField(authType, "clientId"),
+			Authority:             credentialsObj.getField(authType, "authority"),
+			ClientSecret:          getClientSecret(credentialsObj),
+			ClientSecretSecondary: credentialsObj.getField(authType, "azureClientSecretSecondary"),
 		}
-		clientId, err := maputil.GetString(credentialsObj, "clientId")
-		if err != nil {
-			return nil, err
+		return credentials
+
+	case AzureAuthClientCertificate:
+		credentials := &AzureClientCertificateCredentials{
+			AzureCloud:           credentialsObj.getField(authType, "azureCloud"),
+			TenantId:             credentialsObj.getField(authType, "tenantId"),
+			ClientId:             credentialsObj.getField(authType, "clientId"),
+			Authority:            credentialsObj.getField(authType, "authority"),
+			SendCertificateChain: credentialsObj.getBoolField(authType, "sendCertificateChain"),
 		}
-		clientSecret, ok := secureData["azureClientSecret"]
-		if !ok {
-			// Use legacy client secret if it was preserved during migration of credentials
-			clientSecret = secureData["clientSecret"]
+
+		certificateFormat := credentialsObj.getField(authType, "certificateFormat")
+		if !isCertificateFormat(certificateFormat) {
+			// The invalid format is already reported
+			return credentials
 		}
+		credentials.CertificateFormat = certificateFormat
 
-		credentials := &AzureClientSecretCredentials{
-			AzureCloud:   cloud,
-			TenantId:     tenantId,
-			ClientId:     clientId,
-			ClientSecret: clientSecret,
+		credentials.ClientCertificate = credentialsObj.getField(authType, "clientCertificate")
+		credentials.PrivateKey = credentialsObj.getField(authType, "privateKey")
+		credentials.CertificatePassword = credentialsObj.getField(authType, "certificatePassword")
+		if isPrivateKeyRequired(credentials) && credentials.PrivateKey == "" {
+			credentialsObj.addSecureFieldError("privateKey", "no private key provided")
 		}
-		return credentials, nil
+		return credentials
 
-	case AzureAuthClientCertificate:
-		cloud, err := maputil.GetString(credentialsObj, "azureCloud")
-		if err != nil {
-			return nil, err
+	case AzureAuthClientAssertion:
+		credentials := &AzureClientAssertionCredentials{
+			AzureCloud:    credentialsObj.getField(authType, "azureCloud"),
+			TenantId:      credentialsObj.getField(authType, "tenantId"),
+			ClientId:      credentialsObj.getField(authType, "clientId"),
+			AssertionFile: credentialsObj.getField(authType, "assertionFile"),
 		}
-		tenantId, err := maputil.GetString(credentialsObj, "tenantId")
-		if err != nil {
-			return nil, err
+		// The assertion must not be sent to an authority other than the authority of the cloud
+		if authority := credentialsObj.getStringOptional("authority"); authority != "" {
+			credentialsObj.addFieldError("authority", "custom authority not supported for client assertion credentials")
 		}
-		clientId, err := maputil.GetString(credentialsObj, "clientId")
-		if err != nil {
-			return nil, err
+		return credentials
+
+	case AzureAuthClientSecretObo:
+		credentials := &AzureClientSecretOboCredentials{
+			ClientSecretCredentials: AzureClientSecretCredentials{
+				AzureCloud:   credentialsObj.getField(authType, "azureCloud"),
+				TenantId:     credentialsObj.getField(authType, "tenantId"),
+				ClientId:     credentialsObj.getField(authType, "clientId"),
+				Authority:    credentialsObj.getField(authType, "authority"),
+				ClientSecret: getClientSecret(credentialsObj),
+			},
 		}
+		return credentials
 
-		certificateFormat, err := maputil.GetString(credentialsObj, "certificateFormat")
-		if err != nil {
-			return nil, fmt.Errorf("no certificate format provided")
+	case AzureAuthEntraPasswordCredentials:
+		credentials := &AzureEntraPasswordCredentials{
+			UserId:     credentialsObj.getField(authType, "userId"),
+			ClientId:   credentialsObj.getField(authType, "clientId"),
+			TenantId:   credentialsObj.getField(authType, "tenantId"),
+			AzureCloud: credentialsObj.getField(authType, "azureCloud"),
 		}
-		if certificateFormat == "" {
-			return nil, fmt.Errorf("no certificate format provided")
+		if password, ok := credentialsObj.lookupSecure("password"); !ok {
+			credentialsObj.addSecureFieldError("password", "no password provided")
+		} else {
+			credentials.Password = password
 		}
-		if certificateFormat != "pem" && certificateFormat != "pfx" {
-			return nil, fmt.Errorf("invalid certificate format provided")
+		return credentials
+
+	case AzureAuthApiKey:
+		credentials := &AzureApiKeyCredentials{
+			HeaderName: credentialsObj.getField(authType, "headerName"),
+			ApiKey:     credentialsObj.getField(authType, "apiKey"),
 		}
+		return credentials
 
-		var clientCertificate string
-		var privateKey string
-		var certificatePassword string
-		var ok bool
+	case AzureAuthStorageSharedKey:
+		credentials := &AzureStorageSharedKeyCredentials{
+			AccountName: credentialsObj.getField(authType, "accountName"),
+			AccountKey:  credentialsObj.getField(authType, "accountKey"),
+		}
+		return credentials
 
-		clientCertificate, ok = secureData["clientCertificate"]
-		if !ok || clientCertificate == "" {
-			return nil, fmt.Errorf("no certificate provided")
+	case AzureAuthStorageSas:
+		credentials := &AzureStorageSasCredentials{
+			SasToken: credentialsObj.getField(authType, "sasToken"),
 		}
-		switch certificateFormat {
-		case "pem":
-			privateKey, ok = secureData["privateKey"]
-			if !ok || privateKey == "" {
-				return nil, fmt.Errorf("no private key provided")
+		return credentials
+
+	case AzureAuthChained:
+		credentials := &AzureChainedCredentials{}
+		for _, creds := range credentialsObj.getMapList("credentials") {
+			chainedCredentials := getFromCredentialsObject(creds)
+			if chainedCredentials == nil {
+				continue
 			}
-		case "pfx":
-			certificatePassword, ok = secureData["certificatePassword"]
-			if !ok || certificatePassword == "" {
-				return nil, fmt.Errorf("no password provided")
+			if !IsChainableCredentials(chainedCredentials) {
+				creds.addFieldError("authType", fmt.Sprintf("the authentication type '%s' cannot be chained", chainedCredentials.AzureAuthType()))
+				continue
 			}
+			credentials.Credentials = append(credentials.Credentials, chainedCredentials)
 		}
+		return credentials
 
-		credentials := &AzureClientCertificateCredentials{
-			AzureCloud:         cloud,
-			TenantId:           tenantId,
-			ClientId:           clientId,
-			CertificateFormat:  certificateFormat,
-			ClientCertificate:  clientCertificate,
-			PrivateKey:         privateKey,
-			CertificatePassword: certificatePassword,
-		}
-		return credentials, nil
+	default:
+		credentialsObj.addFieldError("authType", fmt.Sprintf("the authentication type '%s' not supported", authType))
+		return nil
+	}
+}
 
-	case AzureAuthClientSecretObo:
-		cloud, err := maputil.GetString(credentialsObj, "azureCloud")
-		if err != nil {
-			return nil, err
-		}
-		tenantId, err := maputil.GetString(credentialsObj, "tenantId")
-		if err != nil {
-			return nil, err
-		}
-		clientId, err := maputil.GetString(credentialsObj, "clientId")
-		if err != nil {
-			return nil, err
-		}
-		clientSecret, ok := secureData["azureClientSecret"]
-		if !ok {
-			// Use legacy client secret if it was preserved during migration of credentials
-			clientSecret = secureData["clientSecret"]
-		}
+// isCertificateFormat returns true for the supported certificate formats, the format is detected from
+// the certificate if it's empty or "auto"
+func isCertificateFormat(certificateFormat string) bool {
+	return mustGetBuiltInField(AzureAuthClientCertificate, "certificateFormat").isAllowed(certificateFormat)
+}
 
-		credentials := &AzureClientSecretOboCredentials{
-			ClientSecretCredentials: AzureClientSecretCredentials{
-				AzureCloud:   cloud,
-				TenantId:     tenantId,
-				ClientId:     clientId,
-				ClientSecret: clientSecret,
-			},
-		}
-		return credentials, nil
-	case AzureAuthEntraPasswordCredentials:
-		userId, err := maputil.GetString(credentialsObj, "userId")
-		if err != nil {
-			return nil, err
-		}
-		clientId, err := maputil.GetString(credentialsObj, "clientId")
-		if err != nil {
-			return nil, err
+// isPrivateKeyRequired returns true if the private key should be provided separately from the certificate,
+// which is the case for DER certificates and for PEM certificates without a private key in the same blob
+func isPrivateKeyRequired(credentials *AzureClientCertificateCredentials) bool {
+	switch credentials.CertificateFormat {
+	case "pem":
+		return !strings.Contains(credentials.ClientCertificate, "PRIVATE KEY-----")
+	case "der":
+		return true
+	default:
+		return false
+	}
+}
+
+func getClientSecret(credentialsObj *dataReader) string {
+	clientSecret, ok := credentialsObj.lookupSecure("azureClientSecret")
+	if !ok {
+		// Use legacy client secret if it was preserved during migration of credentials
+		clientSecret, _ = credentialsObj.lookupSecure("clientSecret")
+	}
+	return clientSecret
+}
+
+// IsChainableCredentials returns false for credentials which cannot be used in AzureChainedCredentials,
+// which are the user identity credentials and the chained credentials themselves.
+func IsChainableCredentials(credentials AzureCredentials) bool {
+	if descriptor, ok := getBuiltInDescriptor(credentials.AzureAuthType()); ok {
+		return descriptor.Chainable
+	}
+	return true
+}
+
+// IsKeyCredentials returns true for credentials which are sent with the requests instead of being exchanged
+// for an access token, which are the API key and the storage account key and SAS credentials.
+func IsKeyCredentials(credentials AzureCredentials) bool {
+	switch credentials.(type) {
+	case *AzureApiKeyCredentials, *AzureStorageSharedKeyCredentials, *AzureStorageSasCredentials:
+		return true
+	default:
+		return false
+	}
+}
+
+const managedIdentityConflictMessage = "only one of 'clientId', 'objectId' or 'resourceId' can be set"
+
+type managedIdentitySelector struct {
+	key   string
+	value string
+}
+
+// getManagedIdentitySelectors returns the managed identity selectors of the credentials in the order of precedence
+func getManagedIdentitySelectors(credentials *AzureManagedIdentityCredentials) []managedIdentitySelector {
+	return []managedIdentitySelector{
+		{"clientId", credentials.ClientId},
+		{"objectId", credentials.ObjectId},
+		{"resourceId", credentials.ResourceId},
+	}
+}
+
+// getManagedIdentityConflict returns false and the key of the conflicting field if more than one
+// managed identity selector is set
+func getManagedIdentityConflict(credentials *AzureManagedIdentityCredentials) (string, bool) {
+	found := false
+	for _, selector := range getManagedIdentitySelectors(credentials) {
+		if selector.value == "" {
+			continue
 		}
-		password, ok := secureData["password"]
-		if !ok {
-			return nil, fmt.Errorf("no password provided")
+		if found {
+			return selector.key, false
 		}
+		found = true
+	}
+	return "", true
+}
 
-		credentials := &AzureEntraPasswordCredentials{
-			Password: password,
-			UserId:   userId,
-			ClientId: clientId,
+// GetManagedIdentitySelector returns the key ('clientId', 'objectId' or 'resourceId') and the value of the
+// managed identity selected by the credentials, or an empty key if the credentials don't select any identity.
+// Returns an error if more than one identity selector is set.
+func GetManagedIdentitySelector(credentials *AzureManagedIdentityCredentials) (string, string, error) {
+	if key, ok := getManagedIdentityConflict(credentials); !ok {
+		return "", "", fmt.Errorf("invalid managed identity credentials, '%s': %s", key, managedIdentityConflictMessage)
+	}
+	for _, selector := range getManagedIdentitySelectors(credentials) {
+		if selector.value != "" {
+			return selector.key, selector.value, nil
 		}
-		return credentials, nil
-	default:
-		err := fmt.Errorf("the authentication type '%s' not supported", authType)
-		return nil, err
 	}
+	return "", "", nil
 }
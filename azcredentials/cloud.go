@@ -6,6 +6,9 @@ import (
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
 )
 
+// GetAzureCloud returns the name of the Azure cloud the credentials authenticate in. For credentials with
+// a custom authority, the cloud is derived from the authority host if it belongs to a predefined or custom
+// cloud, an error is returned if the authority belongs to a cloud other than the configured one.
 func GetAzureCloud(settings *azsettings.AzureSettings, credentials AzureCredentials) (string, error) {
 	switch c := credentials.(type) {
 	case *AadCurrentUserCredentials:
@@ -15,18 +18,61 @@ func GetAzureCloud(settings *azsettings.AzureSettings, credentials AzureCredenti
 		// In case of managed identity, the cloud is always same as where Grafana is hosted
 		return settings.GetDefaultCloud(), nil
 	case *AzureWorkloadIdentityCredentials:
-		// In case of workload identity, the cloud is always same as where Grafana is hosted
+		// In case of workload identity, the cloud is same as where Grafana is hosted unless the cloud
+		// of the app registration is set
+		if c.AzureCloud != "" {
+			return c.AzureCloud, nil
+		}
 		return settings.GetDefaultCloud(), nil
 	case *AzureClientSecretCredentials:
-		return c.AzureCloud, nil
+		return resolveAzureCloud(settings, c.AzureCloud, c.Authority)
 	case *AzureClientCertificateCredentials:
+		return resolveAzureCloud(settings, c.AzureCloud, c.Authority)
+	case *AzureClientAssertionCredentials:
 		return c.AzureCloud, nil
 	case *AzureClientSecretOboCredentials:
-		return c.ClientSecretCredentials.AzureCloud, nil
+		return resolveAzureCloud(settings, c.ClientSecretCredentials.AzureCloud, c.ClientSecretCredentials.Authority)
 	case *AzureEntraPasswordCredentials:
+		if c.AzureCloud != "" {
+			return c.AzureCloud, nil
+		}
+		return settings.GetDefaultCloud(), nil
+	case *AzureApiKeyCredentials, *AzureStorageSharedKeyCredentials, *AzureStorageSasCredentials:
+		// Keys don't authenticate in Entra ID, the cloud is same as where Grafana is hosted
 		return settings.GetDefaultCloud(), nil
+	case *AzureChainedCredentials:
+		// The chain is expected to authenticate in the same cloud, the cloud of the first credentials is used
+		if len(c.Credentials) == 0 {
+			return "", fmt.Errorf("chained credentials should contain at least one credentials")
+		}
+		return GetAzureCloud(settings, c.Credentials[0])
 	default:
 		err := fmt.Errorf("the Azure credentials of type '%s' not supported", c.AzureAuthType())
 		return "", err
 	}
 }
+
+// resolveAzureCloud returns the cloud of the given authority if the authority is set and belongs to a known
+// cloud, otherwise the configured cloud is returned
+func resolveAzureCloud(settings *azsettings.AzureSettings, azureCloud string, authority string) (string, error) {
+	if authority == "" {
+		return azureCloud, nil
+	}
+
+	// The configured cloud takes precedence if it has the authority, e.g. custom cloud with the authority
+	// of a predefined cloud
+	if cloudSettings, err := settings.GetCloud(azureCloud); err == nil && azsettings.IsSameAuthority(cloudSettings.AadAuthority, authority) {
+		return azureCloud, nil
+	}
+
+	authorityCloud, err := settings.GetCloudByAuthority(authority)
+	if err != nil {
+		// Authority of an unknown cloud
+		return azureCloud, nil
+	}
+	if azureCloud != "" && azureCloud != azsettings.AzureCustomized {
+		err = fmt.Errorf("the authority '%s' belongs to the Azure cloud '%s' but the credentials are configured for the Azure cloud '%s'", authority, authorityCloud.Name, azureCloud)
+		return "", err
+	}
+	return authorityCloud.Name, nil
+}
@@ -0,0 +1,119 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAzureCloud(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		Cloud: azsettings.AzureUSGovernment,
+		CustomCloudList: []*azsettings.AzureCloudSettings{
+			{
+				Name:         "CustomCloud",
+				AadAuthority: "https://login.contoso.com/",
+			},
+			{
+				Name:         "CustomPublicCloud",
+				AadAuthority: "https://login.microsoftonline.com/",
+			},
+		},
+	}
+
+	t.Run("should return cloud of Grafana for managed identity", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureUSGovernment, cloud)
+	})
+
+	t.Run("should return cloud of workload identity if set", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureWorkloadIdentityCredentials{AzureCloud: azsettings.AzureChina})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureChina, cloud)
+	})
+
+	t.Run("should return cloud of Grafana for Entra password if cloud not set", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureEntraPasswordCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureUSGovernment, cloud)
+	})
+
+	t.Run("should return cloud of Entra password if set", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureEntraPasswordCredentials{AzureCloud: azsettings.AzurePublic})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzurePublic, cloud)
+	})
+
+	t.Run("should return configured cloud if authority not set", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureClientSecretCredentials{AzureCloud: azsettings.AzureChina})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureChina, cloud)
+	})
+
+	t.Run("should return configured cloud if authority of configured cloud", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureClientCertificateCredentials{
+			AzureCloud: "CustomPublicCloud",
+			Authority:  "https://login.microsoftonline.com/",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "CustomPublicCloud", cloud)
+	})
+
+	t.Run("should derive cloud from authority of customized cloud", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureClientSecretCredentials{
+			AzureCloud: azsettings.AzureCustomized,
+			Authority:  "https://login.contoso.com/",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "CustomCloud", cloud)
+	})
+
+	t.Run("should derive cloud from authority if cloud not set", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureClientSecretOboCredentials{
+			ClientSecretCredentials: AzureClientSecretCredentials{Authority: "https://login.chinacloudapi.cn/"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzureChina, cloud)
+	})
+
+	t.Run("should return configured cloud if authority of unknown cloud", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureClientSecretCredentials{
+			AzureCloud: azsettings.AzurePublic,
+			Authority:  "https://login.fabrikam.com/",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, azsettings.AzurePublic, cloud)
+	})
+
+	t.Run("should return error if authority of other cloud", func(t *testing.T) {
+		_, err := GetAzureCloud(settings, &AzureClientSecretCredentials{
+			AzureCloud: azsettings.AzurePublic,
+			Authority:  "https://login.microsoftonline.us/",
+		})
+		assert.EqualError(t, err, "the authority 'https://login.microsoftonline.us/' belongs to the Azure cloud 'AzureUSGovernment' but the credentials are configured for the Azure cloud 'AzureCloud'")
+	})
+
+	t.Run("should return cloud of first chained credentials", func(t *testing.T) {
+		cloud, err := GetAzureCloud(settings, &AzureChainedCredentials{
+			Credentials: []AzureCredentials{
+				&AzureClientSecretCredentials{AzureCloud: azsettings.AzureCustomized, Authority: "https://login.contoso.com/"},
+				&AzureManagedIdentityCredentials{},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "CustomCloud", cloud)
+	})
+}
@@ -1,5 +1,7 @@
 package azcredentials
 
+import "context"
+
 const (
 	AzureAuthCurrentUserIdentity      = "currentuser"
 	AzureAuthManagedIdentity          = "msi"
@@ -8,6 +10,11 @@ const (
 	AzureAuthClientCertificate        = "clientcertificate"
 	AzureAuthClientSecretObo          = "clientsecret-obo"
 	AzureAuthEntraPasswordCredentials = "ad-password"
+	AzureAuthClientAssertion          = "clientassertion"
+	AzureAuthChained                  = "chained"
+	AzureAuthApiKey                   = "apikey"
+	AzureAuthStorageSharedKey         = "storagesharedkey"
+	AzureAuthStorageSas               = "storagesas"
 )
 
 type AzureCredentials interface {
@@ -23,13 +30,20 @@ type AadCurrentUserCredentials struct {
 // AzureManagedIdentityCredentials "Managed Identity" service managed identity credentials configured
 // for the current Grafana instance.
 type AzureManagedIdentityCredentials struct {
-	ClientId string
+	// Selects a user-assigned managed identity by either client ID, object ID or resource ID, only one of them
+	// can be set. The managed identity configured in Grafana is used if none of them is set.
+	ClientId   string
+	ObjectId   string
+	ResourceId string
 }
 
 // AzureWorkloadIdentityCredentials Uses Azure AD Workload Identity
 type AzureWorkloadIdentityCredentials struct {
-	ClientId string
-	TenantId string
+	// Optional cloud of the app registration, the cloud where Grafana is hosted is used if not set,
+	// other clouds must be allowed in Grafana config
+	AzureCloud string
+	ClientId   string
+	TenantId   string
 }
 
 // AzureClientSecretCredentials "App Registration" AAD service identity credentials configured in the datasource.
@@ -39,27 +53,42 @@ type AzureClientSecretCredentials struct {
 	TenantId     string
 	ClientId     string
 	ClientSecret string
+	// Optional secondary secret used when the primary secret is rejected, so that the secret can be rotated
+	// without downtime
+	ClientSecretSecondary string
 }
 
 // AzureClientCertificateCredentials "App Registration (Certificate)" AAD service identity credentials
 // configured in the datasource.
 type AzureClientCertificateCredentials struct {
-	AzureCloud        string
-	Authority         string
-	TenantId          string
-	ClientId          string
+	AzureCloud string
+	Authority  string
+	TenantId   string
+	ClientId   string
+	// One of "pem", "der" or "pfx", the format is detected from the certificate if empty or "auto"
 	CertificateFormat string
-	// Can either be a plain text PEM certificate or a base64 encoded pfx file  depending on the certificate format
-	ClientCertificate   string
-	PrivateKey          string
+	// Can either be a plain text PEM certificate (optionally bundled with the private key and the chain),
+	// a base64 encoded DER certificate or a base64 encoded pfx file depending on the certificate format
+	ClientCertificate string
+	// PEM or base64 encoded DER private key, required for DER certificates and PEM certificates without
+	// the private key
+	PrivateKey string
+	// Password of the pfx file or of the encrypted PKCS#8 private key
 	CertificatePassword string
+	// Send the certificate chain (x5c header) with the client assertion, required for subject name/issuer
+	// authentication
+	SendCertificateChain bool
 }
 
+// AzureEntraPasswordCredentials "Entra ID Password" user credentials (resource owner password flow) configured
+// in the datasource.
 type AzureEntraPasswordCredentials struct {
-	Password string
-	UserId   string
-	ClientId string
-	TenantId string
+	// Optional cloud of the user, the cloud where Grafana is hosted is used if not set
+	AzureCloud string
+	Password   string
+	UserId     string
+	ClientId   string
+	TenantId   string
 }
 
 // AzureClientSecretOboCredentials "App Registration (On-Behalf-Of)" user identity credentials obtained using
@@ -68,6 +97,55 @@ type AzureClientSecretOboCredentials struct {
 	ClientSecretCredentials AzureClientSecretCredentials
 }
 
+// AzureClientAssertionCredentials "App Registration (Federated Credential)" AAD service identity credentials
+// authenticated with a client assertion signed by an external identity provider trusted by the app registration.
+//
+// The assertion is sent to the authority of the cloud, custom authorities aren't supported.
+type AzureClientAssertionCredentials struct {
+	AzureCloud string
+	TenantId   string
+	ClientId   string
+	// Path to a file with the assertion (e.g. a Kubernetes service account token or a GitHub OIDC token),
+	// the file is read on each token request so that the assertion can be rotated, the file must be allowed
+	// in the Grafana config
+	AssertionFile string
+	// Optional callback returning the assertion, takes precedence over the assertion file,
+	// can only be set programmatically
+	GetAssertion func(ctx context.Context) (string, error)
+	// Key identifying the assertion returned by the callback, required if the callback is set.
+	// Tokens are cached per key, so callbacks returning different assertions must have different keys.
+	AssertionKey string
+}
+
+// AzureChainedCredentials service identity credentials which are tried in order until one of them
+// succeeds to authenticate, so that the same datasource can be used in different environments.
+type AzureChainedCredentials struct {
+	Credentials []AzureCredentials
+}
+
+// AzureApiKeyCredentials "API Key" credentials sent in a request header to services which support
+// API key authentication instead of Entra ID tokens, e.g. Application Insights or Azure OpenAI.
+type AzureApiKeyCredentials struct {
+	// Name of the HTTP header with the API key, e.g. "x-api-key" or "api-key"
+	HeaderName string
+	ApiKey     string
+}
+
+// AzureStorageSharedKeyCredentials "Storage Account Key" credentials of an Azure Storage account, the requests
+// to the Blob, Queue and Table services are signed with the account key.
+type AzureStorageSharedKeyCredentials struct {
+	AccountName string
+	// Base64 encoded primary or secondary key of the storage account
+	AccountKey string
+}
+
+// AzureStorageSasCredentials "Shared Access Signature" credentials of an Azure Storage account, the SAS token
+// is appended to the query of the requests.
+type AzureStorageSasCredentials struct {
+	// SAS token with or without the leading "?", e.g. "sv=2022-11-02&ss=b&srt=co&sp=rl&se=...&sig=..."
+	SasToken string
+}
+
 func (credentials *AadCurrentUserCredentials) AzureAuthType() string {
 	return AzureAuthCurrentUserIdentity
 }
@@ -95,3 +173,23 @@ func (credentials *AzureClientSecretOboCredentials) AzureAuthType() string {
 func (credentials *AzureEntraPasswordCredentials) AzureAuthType() string {
 	return AzureAuthEntraPasswordCredentials
 }
+
+func (credentials *AzureClientAssertionCredentials) AzureAuthType() string {
+	return AzureAuthClientAssertion
+}
+
+func (credentials *AzureChainedCredentials) AzureAuthType() string {
+	return AzureAuthChained
+}
+
+func (credentials *AzureApiKeyCredentials) AzureAuthType() string {
+	return AzureAuthApiKey
+}
+
+func (credentials *AzureStorageSharedKeyCredentials) AzureAuthType() string {
+	return AzureAuthStorageSharedKey
+}
+
+func (credentials *AzureStorageSasCredentials) AzureAuthType() string {
+	return AzureAuthStorageSas
+}
@@ -0,0 +1,401 @@
+package azcredentials
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeBoolean FieldType = "boolean"
+	// Nested credentials object
+	FieldTypeCredentials FieldType = "credentials"
+	// Array of nested credentials objects
+	FieldTypeCredentialsList FieldType = "credentialsList"
+)
+
+// FieldDescriptor describes a field of the `azureCredentials` object in the datasource jsonData,
+// or a field of the datasource secureJsonData if the field is secure.
+type FieldDescriptor struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Secure   bool      `json:"secure,omitempty"`
+	Required bool      `json:"required,omitempty"`
+	// Condition under which the field is required if it's not always required
+	RequiredWhen string `json:"requiredWhen,omitempty"`
+	// Values allowed for the field, any value is allowed if empty
+	Options []string `json:"options,omitempty"`
+	// Only one of the fields with the same exclusive group can be set
+	ExclusiveGroup string `json:"exclusiveGroup,omitempty"`
+	Description    string `json:"description,omitempty"`
+
+	// Name of the field in error messages of the built-in parser
+	label string
+}
+
+// CredentialsDescriptor describes the configuration of an authentication type, so that the configuration
+// editor and tooling don't have to duplicate the knowledge of the fields read by FromDatasourceData.
+type CredentialsDescriptor struct {
+	AuthType    string `json:"authType"`
+	DisplayName string `json:"displayName"`
+	// Whether the credentials require the identity of the signed-in Grafana user
+	UserIdentity bool `json:"userIdentity"`
+	// Whether the credentials can be used in chained credentials and as fallback service credentials
+	Chainable bool              `json:"chainable"`
+	Fields    []FieldDescriptor `json:"fields"`
+}
+
+var (
+	azureCloudField = FieldDescriptor{
+		Name:        "azureCloud",
+		Type:        FieldTypeString,
+		Required:    true,
+		Description: "Name of the Azure cloud, e.g. AzureCloud",
+	}
+	authorityField = FieldDescriptor{
+		Name:        "authority",
+		Type:        FieldTypeString,
+		Description: "Entra ID authority host, the authority of the Azure cloud is used if not set",
+	}
+	tenantIdField = FieldDescriptor{
+		Name:        "tenantId",
+		Type:        FieldTypeString,
+		Required:    true,
+		Description: "Directory (tenant) ID",
+	}
+	clientIdField = FieldDescriptor{
+		Name:        "clientId",
+		Type:        FieldTypeString,
+		Required:    true,
+		Description: "Application (client) ID",
+	}
+	clientSecretField = FieldDescriptor{
+		Name:        "azureClientSecret",
+		Type:        FieldTypeString,
+		Secure:      true,
+		Required:    true,
+		Description: "Client secret of the app registration",
+	}
+)
+
+// optional returns a copy of the field which isn't required
+func optional(field FieldDescriptor) FieldDescriptor {
+	field.Required = false
+	return field
+}
+
+var builtInDescriptors = []*CredentialsDescriptor{
+	{
+		AuthType:     AzureAuthCurrentUserIdentity,
+		DisplayName:  "Current User",
+		UserIdentity: true,
+		Fields: []FieldDescriptor{
+			{
+				Name:        "serviceCredentialsEnabled",
+				Type:        FieldTypeBoolean,
+				Description: "Use the service credentials when the identity of the user isn't available, e.g. for alerting",
+			},
+			{
+				Name:         "serviceCredentials",
+				Type:         FieldTypeCredentials,
+				RequiredWhen: "serviceCredentialsEnabled is true",
+				Description:  "Service credentials of a chainable authentication type",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthManagedIdentity,
+		DisplayName: "Managed Identity",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			{
+				Name:           "clientId",
+				Type:           FieldTypeString,
+				ExclusiveGroup: "managedIdentity",
+				Description:    "Client ID of the user-assigned managed identity",
+			},
+			{
+				Name:           "objectId",
+				Type:           FieldTypeString,
+				ExclusiveGroup: "managedIdentity",
+				Description:    "Object ID of the user-assigned managed identity",
+			},
+			{
+				Name:           "resourceId",
+				Type:           FieldTypeString,
+				ExclusiveGroup: "managedIdentity",
+				Description:    "Resource ID of the user-assigned managed identity",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthWorkloadIdentity,
+		DisplayName: "Workload Identity",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			optional(azureCloudField),
+			optional(tenantIdField),
+			optional(clientIdField),
+		},
+	},
+	{
+		AuthType:    AzureAuthClientSecret,
+		DisplayName: "App Registration",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			azureCloudField,
+			authorityField,
+			tenantIdField,
+			clientIdField,
+			clientSecretField,
+			{
+				Name:        "azureClientSecretSecondary",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Description: "Secondary client secret used when the primary secret is rejected, to rotate the secret without downtime",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthClientCertificate,
+		DisplayName: "App Registration (Certificate)",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			azureCloudField,
+			authorityField,
+			tenantIdField,
+			clientIdField,
+			{
+				Name:        "certificateFormat",
+				Type:        FieldTypeString,
+				Options:     []string{"auto", "pem", "der", "pfx"},
+				Description: "Format of the certificate, the format is detected from the certificate if not set or auto",
+				label:       "certificate format",
+			},
+			{
+				Name:        "sendCertificateChain",
+				Type:        FieldTypeBoolean,
+				Description: "Send the certificate chain with the client assertion, required for subject name/issuer authentication",
+			},
+			{
+				Name:        "clientCertificate",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Required:    true,
+				Description: "PEM certificate, base64 encoded DER certificate or base64 encoded pfx file",
+				label:       "certificate",
+			},
+			{
+				Name:         "privateKey",
+				Type:         FieldTypeString,
+				Secure:       true,
+				RequiredWhen: "certificateFormat is der, or pem without the private key in the certificate",
+				Description:  "PEM or base64 encoded DER private key",
+				label:        "private key",
+			},
+			{
+				Name:        "certificatePassword",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Description: "Password of the pfx file or of the encrypted private key",
+			},
+		},
+	},
+	{
+		AuthType:     AzureAuthClientSecretObo,
+		DisplayName:  "App Registration (On-Behalf-Of)",
+		UserIdentity: true,
+		Fields: []FieldDescriptor{
+			azureCloudField,
+			authorityField,
+			tenantIdField,
+			clientIdField,
+			clientSecretField,
+		},
+	},
+	{
+		AuthType:    AzureAuthEntraPasswordCredentials,
+		DisplayName: "Entra ID Password",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			optional(azureCloudField),
+			optional(tenantIdField),
+			clientIdField,
+			{
+				Name:        "userId",
+				Type:        FieldTypeString,
+				Required:    true,
+				Description: "User principal name of the Entra ID user",
+			},
+			{
+				Name:        "password",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Required:    true,
+				Description: "Password of the Entra ID user",
+				label:       "password",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthClientAssertion,
+		DisplayName: "App Registration (Federated Credential)",
+		Chainable:   true,
+		Fields: []FieldDescriptor{
+			azureCloudField,
+			tenantIdField,
+			clientIdField,
+			{
+				Name:        "assertionFile",
+				Type:        FieldTypeString,
+				Required:    true,
+				Description: "Path to the file with the client assertion on the Grafana instance, must be allowed in Grafana config",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthApiKey,
+		DisplayName: "API Key",
+		Fields: []FieldDescriptor{
+			{
+				Name:        "headerName",
+				Type:        FieldTypeString,
+				Required:    true,
+				Description: "Name of the HTTP header with the API key, e.g. x-api-key or api-key",
+			},
+			{
+				Name:        "apiKey",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Required:    true,
+				Description: "API key of the service",
+				label:       "API key",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthStorageSharedKey,
+		DisplayName: "Storage Account Key",
+		Fields: []FieldDescriptor{
+			{
+				Name:        "accountName",
+				Type:        FieldTypeString,
+				Required:    true,
+				Description: "Name of the storage account",
+			},
+			{
+				Name:        "accountKey",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Required:    true,
+				Description: "Base64 encoded key of the storage account",
+				label:       "account key",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthStorageSas,
+		DisplayName: "Shared Access Signature",
+		Fields: []FieldDescriptor{
+			{
+				Name:        "sasToken",
+				Type:        FieldTypeString,
+				Secure:      true,
+				Required:    true,
+				Description: "SAS token of the storage account or of the storage resource",
+				label:       "SAS token",
+			},
+		},
+	},
+	{
+		AuthType:    AzureAuthChained,
+		DisplayName: "Chained",
+		Fields: []FieldDescriptor{
+			{
+				Name:        "credentials",
+				Type:        FieldTypeCredentialsList,
+				Required:    true,
+				Description: "Credentials of chainable authentication types tried in order",
+			},
+		},
+	},
+}
+
+// GetCredentialsDescriptor returns the descriptor of the given authentication type, custom authentication
+// types have a descriptor only if it was registered with RegisterCredentialsDescriptor.
+func GetCredentialsDescriptor(authType string) (*CredentialsDescriptor, bool) {
+	if descriptor, ok := getBuiltInDescriptor(authType); ok {
+		return descriptor.clone(), true
+	}
+	if descriptor, ok := getCustomDescriptor(authType); ok {
+		return descriptor.clone(), true
+	}
+	return nil, false
+}
+
+// GetCredentialsDescriptors returns the descriptors of the built-in authentication types followed by
+// the descriptors registered for custom authentication types.
+func GetCredentialsDescriptors() []*CredentialsDescriptor {
+	customDescriptorsMu.RLock()
+	defer customDescriptorsMu.RUnlock()
+
+	descriptors := make([]*CredentialsDescriptor, 0, len(builtInDescriptors)+len(customDescriptors))
+	for _, descriptor := range builtInDescriptors {
+		descriptors = append(descriptors, descriptor.clone())
+	}
+
+	customAuthTypes := make([]string, 0, len(customDescriptors))
+	for authType := range customDescriptors {
+		customAuthTypes = append(customAuthTypes, authType)
+	}
+	sort.Strings(customAuthTypes)
+	for _, authType := range customAuthTypes {
+		descriptors = append(descriptors, customDescriptors[authType].clone())
+	}
+
+	return descriptors
+}
+
+func getBuiltInDescriptor(authType string) (*CredentialsDescriptor, bool) {
+	for _, descriptor := range builtInDescriptors {
+		if descriptor.AuthType == authType {
+			return descriptor, true
+		}
+	}
+	return nil, false
+}
+
+// mustGetBuiltInField returns the definition of the field of the built-in authentication type
+// read by the builder
+func mustGetBuiltInField(authType string, name string) *FieldDescriptor {
+	if descriptor, ok := getBuiltInDescriptor(authType); ok {
+		for i := range descriptor.Fields {
+			if descriptor.Fields[i].Name == name {
+				return &descriptor.Fields[i]
+			}
+		}
+	}
+	panic(fmt.Sprintf("field '%s' not defined for the authentication type '%s'", name, authType))
+}
+
+func (descriptor *CredentialsDescriptor) clone() *CredentialsDescriptor {
+	result := *descriptor
+	result.Fields = make([]FieldDescriptor, 0, len(descriptor.Fields))
+	for _, field := range descriptor.Fields {
+		field.Options = slices.Clone(field.Options)
+		result.Fields = append(result.Fields, field)
+	}
+	return &result
+}
+
+// isAllowed returns true if the value is one of the options of the field, an empty value is allowed
+// for fields which aren't required
+func (field *FieldDescriptor) isAllowed(value string) bool {
+	if value == "" {
+		return !field.Required
+	}
+	return len(field.Options) == 0 || slices.Contains(field.Options, value)
+}
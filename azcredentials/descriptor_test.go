@@ -0,0 +1,212 @@
+package azcredentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allAuthTypes = []string{
+	AzureAuthCurrentUserIdentity,
+	AzureAuthManagedIdentity,
+	AzureAuthWorkloadIdentity,
+	AzureAuthClientSecret,
+	AzureAuthClientCertificate,
+	AzureAuthClientSecretObo,
+	AzureAuthEntraPasswordCredentials,
+	AzureAuthClientAssertion,
+	AzureAuthApiKey,
+	AzureAuthStorageSharedKey,
+	AzureAuthStorageSas,
+	AzureAuthChained,
+}
+
+// getDatasourceDataFromDescriptor returns datasource data with all the fields of the descriptor set,
+// except the fields excluded by another field of the same exclusive group
+func getDatasourceDataFromDescriptor(descriptor *CredentialsDescriptor, secureData map[string]string) map[string]interface{} {
+	credentialsObj := map[string]interface{}{
+		"authType": descriptor.AuthType,
+	}
+	exclusiveGroups := map[string]bool{}
+	for _, field := range descriptor.Fields {
+		if field.ExclusiveGroup != "" {
+			if exclusiveGroups[field.ExclusiveGroup] {
+				continue
+			}
+			exclusiveGroups[field.ExclusiveGroup] = true
+		}
+
+		var value interface{}
+		switch field.Type {
+		case FieldTypeString:
+			switch {
+			case field.Name == "azureCloud":
+				value = azsettings.AzurePublic
+			case field.Name == "accountKey":
+				value = base64.StdEncoding.EncodeToString([]byte("FAKE-" + field.Name))
+			case field.Name == "sasToken":
+				value = "sv=2022-11-02&sig=FAKE-" + field.Name
+			case field.Name == "assertionFile":
+				value = "/var/run/secrets/FAKE-" + field.Name
+			case len(field.Options) > 0:
+				value = field.Options[0]
+			default:
+				value = "FAKE-" + field.Name
+			}
+		case FieldTypeBoolean:
+			value = true
+		case FieldTypeCredentials:
+			value = map[string]interface{}{"authType": AzureAuthManagedIdentity}
+		case FieldTypeCredentialsList:
+			value = []interface{}{map[string]interface{}{"authType": AzureAuthManagedIdentity}}
+		}
+
+		if field.Secure {
+			secureData[field.Name] = value.(string)
+		} else {
+			credentialsObj[field.Name] = value
+		}
+	}
+	return credentialsObj
+}
+
+func TestGetCredentialsDescriptor(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		ManagedIdentityEnabled:          true,
+		ManagedIdentityAllowedClientIds: []string{"FAKE-clientId"},
+		WorkloadIdentityEnabled:         true,
+		WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+			AllowedClientIds: []string{"FAKE-clientId"},
+		},
+		UserIdentityEnabled:                  true,
+		AzureEntraPasswordCredentialsEnabled: true,
+		ClientAssertionCredentialsEnabled:    true,
+		ClientAssertionAllowedFiles:          []string{"/var/run/secrets/FAKE-assertionFile"},
+	}
+
+	t.Run("should return descriptor for all built-in authentication types", func(t *testing.T) {
+		for _, authType := range allAuthTypes {
+			descriptor, ok := GetCredentialsDescriptor(authType)
+			require.True(t, ok, authType)
+			assert.Equal(t, authType, descriptor.AuthType)
+			assert.NotEmpty(t, descriptor.DisplayName)
+		}
+	})
+
+	t.Run("should not return descriptor for unknown authentication type", func(t *testing.T) {
+		_, ok := GetCredentialsDescriptor("unknown")
+		assert.False(t, ok)
+	})
+
+	t.Run("should describe fields read from datasource data", func(t *testing.T) {
+		for _, authType := range allAuthTypes {
+			descriptor, _ := GetCredentialsDescriptor(authType)
+			secureData := map[string]string{}
+			data := map[string]interface{}{
+				"azureCredentials": getDatasourceDataFromDescriptor(descriptor, secureData),
+			}
+
+			credentials, err := FromDatasourceDataWithValidation(settings, data, secureData)
+			require.NoError(t, err, authType)
+
+			// All the described fields are read and written back
+			resultData, resultSecureData, err := ToDatasourceData(credentials)
+			require.NoError(t, err)
+			assert.Equal(t, data, resultData, authType)
+			assert.Equal(t, secureData, resultSecureData, authType)
+		}
+	})
+
+	t.Run("should report missing required fields", func(t *testing.T) {
+		for _, authType := range allAuthTypes {
+			descriptor, _ := GetCredentialsDescriptor(authType)
+			for _, field := range descriptor.Fields {
+				if !field.Required {
+					continue
+				}
+
+				secureData := map[string]string{}
+				credentialsObj := getDatasourceDataFromDescriptor(descriptor, secureData)
+				expectedPath := "azureCredentials." + field.Name
+				if field.Secure {
+					delete(secureData, field.Name)
+					expectedPath = "secureJsonData." + field.Name
+				} else {
+					delete(credentialsObj, field.Name)
+				}
+				data := map[string]interface{}{"azureCredentials": credentialsObj}
+
+				_, err := FromDatasourceDataWithValidation(settings, data, secureData)
+				require.Error(t, err, "%s.%s", authType, field.Name)
+
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.True(t, hasFieldError(validationErr.Errors, expectedPath), "%s: %s", expectedPath, err)
+			}
+		}
+	})
+
+	t.Run("should report value not in options", func(t *testing.T) {
+		_, err := FromDatasourceData(map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":          AzureAuthClientCertificate,
+				"azureCloud":        azsettings.AzurePublic,
+				"tenantId":          "TENANT-ID",
+				"clientId":          "CLIENT-ID",
+				"certificateFormat": "p7b",
+			},
+		}, map[string]string{"clientCertificate": "FAKE-CERTIFICATE"})
+
+		assert.EqualError(t, err, "azureCredentials.certificateFormat: invalid certificate format provided")
+	})
+
+	t.Run("should return copy of descriptor", func(t *testing.T) {
+		descriptor, _ := GetCredentialsDescriptor(AzureAuthClientCertificate)
+		for i := range descriptor.Fields {
+			descriptor.Fields[i].Required = false
+			descriptor.Fields[i].Options = nil
+		}
+
+		assert.True(t, mustGetBuiltInField(AzureAuthClientCertificate, "clientCertificate").Required)
+		assert.False(t, isCertificateFormat("p7b"))
+	})
+
+	t.Run("should describe chainable credentials", func(t *testing.T) {
+		for _, authType := range allAuthTypes {
+			descriptor, _ := GetCredentialsDescriptor(authType)
+			credentials, _ := FromDatasourceData(map[string]interface{}{
+				"azureCredentials": map[string]interface{}{"authType": authType},
+			}, map[string]string{})
+			if credentials == nil {
+				continue
+			}
+
+			assert.Equal(t, IsChainableCredentials(credentials), descriptor.Chainable, authType)
+		}
+	})
+}
+
+func TestGetCredentialsDescriptors(t *testing.T) {
+	t.Run("should return built-in descriptors in order", func(t *testing.T) {
+		descriptors := GetCredentialsDescriptors()
+
+		require.Len(t, descriptors, len(allAuthTypes))
+		for i, authType := range allAuthTypes {
+			assert.Equal(t, authType, descriptors[i].AuthType)
+		}
+	})
+
+	t.Run("should serialize descriptors to JSON", func(t *testing.T) {
+		descriptor, _ := GetCredentialsDescriptor(AzureAuthClientSecret)
+
+		descriptorJson, err := json.Marshal(descriptor)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(descriptorJson), `"authType":"clientsecret"`)
+		assert.Contains(t, string(descriptorJson), `{"name":"azureClientSecret","type":"string","secure":true,"required":true,"description":"Client secret of the app registration"}`)
+	})
+}
@@ -0,0 +1,261 @@
+package azcredentials
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// fingerprintKey is the random key of the fingerprint HMAC, so that the fingerprints cannot be used
+// to guess the secrets offline
+var fingerprintKey = newFingerprintKey()
+
+func newFingerprintKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate fingerprint key: %s", err))
+	}
+	return key
+}
+
+// FingerprintedCredentials is implemented by credentials which have a stable fingerprint, which is equal
+// for equal credentials and changes whenever any of the fields or secrets change.
+type FingerprintedCredentials interface {
+	AzureCredentials
+	Fingerprint() string
+	Equal(other AzureCredentials) bool
+}
+
+// GetFingerprint returns the fingerprint of the given credentials. The fingerprint is an HMAC-SHA256
+// of all the fields of the credentials with a random key generated for each process, so it's only stable
+// within the same process and must not be persisted. Credentials of custom authentication types have
+// a fingerprint only if they implement FingerprintedCredentials.
+func GetFingerprint(credentials AzureCredentials) (string, error) {
+	if credentials == nil {
+		return "", fmt.Errorf("parameter 'credentials' cannot be nil")
+	}
+
+	if !isBuiltInCredentials(credentials) {
+		if c, ok := credentials.(FingerprintedCredentials); ok {
+			return c.Fingerprint(), nil
+		}
+		return "", fmt.Errorf("fingerprint of the authentication type '%s' not supported", credentials.AzureAuthType())
+	}
+
+	fingerprintObj, err := getFingerprintObject(credentials)
+	if err != nil {
+		return "", err
+	}
+	fingerprintJson, err := json.Marshal(fingerprintObj)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, fingerprintKey)
+	mac.Write(fingerprintJson)
+	return fmt.Sprintf("%x", mac.Sum(nil)), nil
+}
+
+// CredentialsEqual returns true if both credentials are of the same authentication type and have equal
+// fingerprints. Credentials without a fingerprint are never equal.
+func CredentialsEqual(credentials AzureCredentials, other AzureCredentials) bool {
+	if credentials == nil || other == nil {
+		return credentials == nil && other == nil
+	}
+	if credentials.AzureAuthType() != other.AzureAuthType() {
+		return false
+	}
+
+	fingerprint, err := GetFingerprint(credentials)
+	if err != nil {
+		return false
+	}
+	otherFingerprint, err := GetFingerprint(other)
+	if err != nil {
+		return false
+	}
+	return fingerprint == otherFingerprint
+}
+
+// getFingerprintObject returns the credentials in the datasource data form, with the secrets stored
+// in the `secureJsonData` object of each credentials
+func getFingerprintObject(credentials AzureCredentials) (map[string]interface{}, error) {
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		fingerprintObj := map[string]interface{}{
+			"authType":                  c.AzureAuthType(),
+			"serviceCredentialsEnabled": c.ServiceCredentialsEnabled,
+		}
+		if c.ServiceCredentials != nil {
+			serviceFingerprintObj, err := getNestedFingerprintObject(c.ServiceCredentials)
+			if err != nil {
+				return nil, err
+			}
+			fingerprintObj["serviceCredentials"] = serviceFingerprintObj
+		}
+		return fingerprintObj, nil
+
+	case *AzureChainedCredentials:
+		chainedFingerprintObjs := make([]interface{}, 0, len(c.Credentials))
+		for _, chainedCredentials := range c.Credentials {
+			chainedFingerprintObj, err := getNestedFingerprintObject(chainedCredentials)
+			if err != nil {
+				return nil, err
+			}
+			chainedFingerprintObjs = append(chainedFingerprintObjs, chainedFingerprintObj)
+		}
+		return map[string]interface{}{
+			"authType":    c.AzureAuthType(),
+			"credentials": chainedFingerprintObjs,
+		}, nil
+
+	case *AzureClientAssertionCredentials:
+		if c.GetAssertion != nil {
+			return map[string]interface{}{
+				"authType":     c.AzureAuthType(),
+				"azureCloud":   c.AzureCloud,
+				"tenantId":     c.TenantId,
+				"clientId":     c.ClientId,
+				"assertionKey": c.AssertionKey,
+			}, nil
+		}
+	}
+
+	secureData := map[string]string{}
+	fingerprintObj, err := getCredentialsObject(credentials, secureData)
+	if err != nil {
+		return nil, err
+	}
+	fingerprintObj["secureJsonData"] = secureData
+	return fingerprintObj, nil
+}
+
+// getNestedFingerprintObject returns the fingerprint object of the nested credentials, which can be
+// of a custom authentication type
+func getNestedFingerprintObject(credentials AzureCredentials) (interface{}, error) {
+	if isBuiltInCredentials(credentials) {
+		return getFingerprintObject(credentials)
+	}
+	return GetFingerprint(credentials)
+}
+
+func isBuiltInCredentials(credentials AzureCredentials) bool {
+	switch credentials.(type) {
+	case *AadCurrentUserCredentials, *AzureManagedIdentityCredentials, *AzureWorkloadIdentityCredentials,
+		*AzureClientSecretCredentials, *AzureClientCertificateCredentials, *AzureClientSecretOboCredentials,
+		*AzureEntraPasswordCredentials, *AzureClientAssertionCredentials, *AzureChainedCredentials,
+		*AzureApiKeyCredentials, *AzureStorageSharedKeyCredentials, *AzureStorageSasCredentials:
+		return true
+	default:
+		return false
+	}
+}
+
+func (credentials *AadCurrentUserCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AadCurrentUserCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureManagedIdentityCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureManagedIdentityCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureWorkloadIdentityCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureWorkloadIdentityCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureClientSecretCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureClientSecretCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureClientCertificateCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureClientCertificateCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureClientSecretOboCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureClientSecretOboCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureEntraPasswordCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureEntraPasswordCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureClientAssertionCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureClientAssertionCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureChainedCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureChainedCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureApiKeyCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureApiKeyCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureStorageSharedKeyCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureStorageSharedKeyCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
+
+func (credentials *AzureStorageSasCredentials) Fingerprint() string {
+	fingerprint, _ := GetFingerprint(credentials)
+	return fingerprint
+}
+
+func (credentials *AzureStorageSasCredentials) Equal(other AzureCredentials) bool {
+	return CredentialsEqual(credentials, other)
+}
@@ -0,0 +1,120 @@
+package azcredentials
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+)
+
+// Fields of the legacy datasource configuration where the credentials were stored at the root of jsonData
+var legacyCredentialsFields = []string{"azureAuthType", "cloudName", "tenantId", "clientId"}
+
+// Cloud names used by the legacy configuration of the Azure Monitor datasource
+var legacyCloudNames = map[string]string{
+	"azuremonitor":           azsettings.AzurePublic,
+	"chinaazuremonitor":      azsettings.AzureChina,
+	"govazuremonitor":        azsettings.AzureUSGovernment,
+	"customizedazuremonitor": azsettings.AzureCustomized,
+}
+
+// MigrateLegacyDatasourceData reads the credentials from the datasource data, migrating the legacy layout
+// where the credentials were stored at the root of jsonData (`azureAuthType`, `cloudName`, `tenantId`,
+// `clientId` and the secure `clientSecret`).
+//
+// Returns the credentials and the jsonData with the credentials stored in the `azureCredentials` object and
+// the legacy fields removed. The given data isn't modified. The secure `clientSecret` is left in place as
+// secureJsonData cannot be rewritten by the plugin, FromDatasourceData falls back to it when reading
+// the migrated data.
+//
+// If the data isn't in the legacy layout, the credentials are read with FromDatasourceData and the data is
+// returned unchanged.
+func MigrateLegacyDatasourceData(data map[string]interface{}, secureData map[string]string) (AzureCredentials, map[string]interface{}, error) {
+	if !isLegacyDatasourceData(data) {
+		credentials, err := FromDatasourceData(data, secureData)
+		if err != nil {
+			return nil, nil, err
+		}
+		return credentials, data, nil
+	}
+
+	credentials, err := getFromLegacyData(data, secureData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credentialsObj, err := getCredentialsObject(credentials, map[string]string{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migratedData := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		migratedData[key] = value
+	}
+	for _, key := range legacyCredentialsFields {
+		delete(migratedData, key)
+	}
+	migratedData[credentialsPath] = credentialsObj
+
+	return credentials, migratedData, nil
+}
+
+func isLegacyDatasourceData(data map[string]interface{}) bool {
+	if _, ok := data[credentialsPath]; ok {
+		return false
+	}
+	for _, key := range legacyCredentialsFields {
+		if _, ok := data[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func getFromLegacyData(data map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+	reader := newDataReader(data, secureData)
+
+	authType := reader.getStringOptional("azureAuthType")
+	if authType == "" {
+		// Legacy configuration without the authentication type always used an app registration
+		authType = AzureAuthClientSecret
+	}
+
+	var credentials AzureCredentials
+	switch authType {
+	case AzureAuthManagedIdentity:
+		credentials = &AzureManagedIdentityCredentials{}
+
+	case AzureAuthWorkloadIdentity:
+		credentials = &AzureWorkloadIdentityCredentials{
+			TenantId: reader.getStringOptional("tenantId"),
+			ClientId: reader.getStringOptional("clientId"),
+		}
+
+	case AzureAuthClientSecret:
+		credentials = &AzureClientSecretCredentials{
+			AzureCloud:   normalizeLegacyCloud(reader.getStringOptional("cloudName")),
+			TenantId:     reader.getString("tenantId"),
+			ClientId:     reader.getString("clientId"),
+			ClientSecret: getClientSecret(reader),
+		}
+
+	default:
+		reader.addFieldError("azureAuthType", fmt.Sprintf("the authentication type '%s' not supported", authType))
+	}
+
+	if len(reader.errs.list) > 0 {
+		return nil, reader.errs.list[0]
+	}
+	return credentials, nil
+}
+
+func normalizeLegacyCloud(cloudName string) string {
+	if cloudName == "" {
+		return azsettings.AzurePublic
+	}
+	if azureCloud, ok := legacyCloudNames[cloudName]; ok {
+		return azureCloud
+	}
+	return azsettings.NormalizeAzureCloud(cloudName)
+}
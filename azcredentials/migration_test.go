@@ -0,0 +1,190 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateLegacyDatasourceData(t *testing.T) {
+	t.Run("should return nil when no credentials configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"subscriptionId": "SUBSCRIPTION-ID",
+		}
+		var secureData = map[string]string{}
+
+		credentials, migratedData, err := MigrateLegacyDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		assert.Nil(t, credentials)
+		assert.Equal(t, data, migratedData)
+	})
+
+	t.Run("should return credentials and unchanged data when azureCredentials configured", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+			"tenantId": "TENANT-ID",
+		}
+		var secureData = map[string]string{}
+
+		credentials, migratedData, err := MigrateLegacyDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, credentials)
+		assert.Equal(t, data, migratedData)
+	})
+
+	t.Run("should migrate legacy client secret credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType":  "clientsecret",
+			"cloudName":      "govazuremonitor",
+			"tenantId":       "TENANT-ID",
+			"clientId":       "CLIENT-ID",
+			"subscriptionId": "SUBSCRIPTION-ID",
+		}
+		var secureData = map[string]string{
+			"clientSecret": "FAKE-LEGACY-SECRET",
+		}
+
+		credentials, migratedData, err := MigrateLegacyDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzureUSGovernment,
+			TenantId:     "TENANT-ID",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-LEGACY-SECRET",
+		}, credentials)
+
+		assert.Equal(t, map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureUSGovernment",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-ID",
+			},
+			"subscriptionId": "SUBSCRIPTION-ID",
+		}, migratedData)
+
+		// Original data should not be modified
+		assert.Equal(t, "TENANT-ID", data["tenantId"])
+		assert.NotContains(t, data, "azureCredentials")
+	})
+
+	t.Run("should read migrated data with FromDatasourceData", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"cloudName":     "chinaazuremonitor",
+			"tenantId":      "TENANT-ID",
+			"clientId":      "CLIENT-ID",
+		}
+		var secureData = map[string]string{
+			"clientSecret": "FAKE-LEGACY-SECRET",
+		}
+
+		credentials, migratedData, err := MigrateLegacyDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		result, err := FromDatasourceData(migratedData, secureData)
+		require.NoError(t, err)
+		assert.Equal(t, credentials, result)
+	})
+
+	t.Run("should normalize cloud name", func(t *testing.T) {
+		tests := []struct {
+			cloudName     string
+			expectedCloud string
+		}{
+			{cloudName: "", expectedCloud: azsettings.AzurePublic},
+			{cloudName: "azuremonitor", expectedCloud: azsettings.AzurePublic},
+			{cloudName: "AzurePublicCloud", expectedCloud: azsettings.AzurePublic},
+			{cloudName: "chinaazuremonitor", expectedCloud: azsettings.AzureChina},
+			{cloudName: "usgov", expectedCloud: azsettings.AzureUSGovernment},
+			{cloudName: "customizedazuremonitor", expectedCloud: azsettings.AzureCustomized},
+			{cloudName: "CustomCloud", expectedCloud: "CustomCloud"},
+		}
+
+		for _, tt := range tests {
+			var data = map[string]interface{}{
+				"azureAuthType": "clientsecret",
+				"cloudName":     tt.cloudName,
+				"tenantId":      "TENANT-ID",
+				"clientId":      "CLIENT-ID",
+			}
+
+			credentials, _, err := MigrateLegacyDatasourceData(data, map[string]string{})
+			require.NoError(t, err)
+
+			require.IsType(t, &AzureClientSecretCredentials{}, credentials)
+			assert.Equal(t, tt.expectedCloud, credentials.(*AzureClientSecretCredentials).AzureCloud)
+		}
+	})
+
+	t.Run("should migrate legacy credentials without authentication type as client secret", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"tenantId": "TENANT-ID",
+			"clientId": "CLIENT-ID",
+		}
+		var secureData = map[string]string{
+			"clientSecret": "FAKE-LEGACY-SECRET",
+		}
+
+		credentials, _, err := MigrateLegacyDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureClientSecretCredentials{}, credentials)
+		assert.Equal(t, azsettings.AzurePublic, credentials.(*AzureClientSecretCredentials).AzureCloud)
+	})
+
+	t.Run("should migrate legacy managed identity credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType": "msi",
+			"cloudName":     "azuremonitor",
+		}
+
+		credentials, migratedData, err := MigrateLegacyDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureManagedIdentityCredentials{}, credentials)
+		assert.Equal(t, map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+		}, migratedData)
+	})
+
+	t.Run("should migrate legacy workload identity credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType": "workloadidentity",
+			"tenantId":      "TENANT-ID",
+		}
+
+		credentials, _, err := MigrateLegacyDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		assert.Equal(t, &AzureWorkloadIdentityCredentials{TenantId: "TENANT-ID"}, credentials)
+	})
+
+	t.Run("should return error when legacy client secret credentials incomplete", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType": "clientsecret",
+			"clientId":      "CLIENT-ID",
+		}
+
+		_, _, err := MigrateLegacyDatasourceData(data, map[string]string{})
+		assert.ErrorContains(t, err, "tenantId")
+	})
+
+	t.Run("should return error when legacy authentication type not supported", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureAuthType": "currentuser",
+		}
+
+		_, _, err := MigrateLegacyDatasourceData(data, map[string]string{})
+		assert.ErrorContains(t, err, "the authentication type 'currentuser' not supported")
+	})
+}
@@ -0,0 +1,220 @@
+package azcredentials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
+)
+
+const (
+	credentialsPath = "azureCredentials"
+	secureDataPath  = "secureJsonData"
+)
+
+// dataReader reads fields of the datasource data and records errors with the JSON path of the field
+// so that all the problems in the configuration can be reported at once
+type dataReader struct {
+	obj        map[string]interface{}
+	secureData map[string]string
+	path       string
+	// Prefix of the secure data keys of the object, see getItemSecurePrefix
+	securePrefix string
+	errs         *fieldErrors
+}
+
+type fieldErrors struct {
+	list []*FieldError
+}
+
+func newDataReader(data map[string]interface{}, secureData map[string]string) *dataReader {
+	return &dataReader{
+		obj:        data,
+		secureData: secureData,
+		errs:       &fieldErrors{},
+	}
+}
+
+func (r *dataReader) fieldPath(key string) string {
+	if r.path == "" {
+		return key
+	}
+	return r.path + "." + key
+}
+
+func (r *dataReader) addFieldError(key string, message string) {
+	r.errs.list = append(r.errs.list, &FieldError{Path: r.fieldPath(key), Message: message})
+}
+
+func (r *dataReader) addSecureFieldError(key string, message string) {
+	r.errs.list = append(r.errs.list, &FieldError{Path: secureDataPath + "." + r.securePrefix + key, Message: message})
+}
+
+// addError records an error returned by a custom parser
+func (r *dataReader) addError(err error) {
+	r.errs.list = append(r.errs.list, toFieldErrors(err, r.path)...)
+}
+
+// toFieldErrors converts the given error to field errors, errors without a path are attributed to
+// the object at the given path
+func toFieldErrors(err error, path string) []*FieldError {
+	var validationErr *ValidationError
+	var fieldErr *FieldError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Errors
+	case errors.As(err, &fieldErr):
+		return []*FieldError{fieldErr}
+	default:
+		return []*FieldError{{Path: path, Message: err.Error(), err: err}}
+	}
+}
+
+func (r *dataReader) lookupString(key string) (string, error) {
+	return maputil.GetString(r.obj, key)
+}
+
+func (r *dataReader) getString(key string) string {
+	value, err := maputil.GetString(r.obj, key)
+	if err != nil {
+		r.addFieldError(key, err.Error())
+	}
+	return value
+}
+
+func (r *dataReader) getStringOptional(key string) string {
+	value, err := maputil.GetStringOptional(r.obj, key)
+	if err != nil {
+		r.addFieldError(key, err.Error())
+	}
+	return value
+}
+
+func (r *dataReader) getBoolOptional(key string) bool {
+	value, err := maputil.GetBoolOptional(r.obj, key)
+	if err != nil {
+		r.addFieldError(key, err.Error())
+	}
+	return value
+}
+
+// getMapOptional returns a reader of the nested object or nil if the object isn't set or invalid
+func (r *dataReader) getMapOptional(key string) *dataReader {
+	value, err := maputil.GetMapOptional(r.obj, key)
+	if err != nil {
+		r.addFieldError(key, err.Error())
+		return nil
+	}
+	if value == nil {
+		return nil
+	}
+	return &dataReader{
+		obj:          value,
+		secureData:   r.secureData,
+		path:         r.fieldPath(key),
+		securePrefix: r.securePrefix,
+		errs:         r.errs,
+	}
+}
+
+// getMapList returns readers of the objects in the array, the array is required and must not be empty
+func (r *dataReader) getMapList(key string) []*dataReader {
+	untypedValue, ok := r.obj[key]
+	if !ok {
+		r.addFieldError(key, fmt.Sprintf("the field '%s' should be set", key))
+		return nil
+	}
+	values, ok := untypedValue.([]interface{})
+	if !ok {
+		r.addFieldError(key, fmt.Sprintf("the field '%s' should be an array", key))
+		return nil
+	}
+	if len(values) == 0 {
+		r.addFieldError(key, fmt.Sprintf("the field '%s' should not be empty", key))
+		return nil
+	}
+
+	readers := make([]*dataReader, 0, len(values))
+	for i, untypedItem := range values {
+		itemPath := fmt.Sprintf("%s[%d]", r.fieldPath(key), i)
+		item, ok := untypedItem.(map[string]interface{})
+		if !ok {
+			r.errs.list = append(r.errs.list, &FieldError{Path: itemPath, Message: fmt.Sprintf("the item of '%s' should be an object", key)})
+			continue
+		}
+		readers = append(readers, &dataReader{
+			obj:          item,
+			secureData:   r.secureData,
+			path:         itemPath,
+			securePrefix: getItemSecurePrefix(r.securePrefix, key, i),
+			errs:         r.errs,
+		})
+	}
+	return readers
+}
+
+// getItemSecurePrefix returns the prefix of the secure data keys of the item of the array at the given index,
+// e.g. `credentials.0.`, so that the secure fields of the items don't overwrite each other
+func getItemSecurePrefix(securePrefix string, key string, i int) string {
+	return fmt.Sprintf("%s%s.%d.", securePrefix, key, i)
+}
+
+func (r *dataReader) lookupSecure(key string) (string, bool) {
+	value, ok := r.secureData[r.securePrefix+key]
+	return value, ok
+}
+
+// getSecureData returns the secure data of the object with the keys without the secure prefix of the object
+func (r *dataReader) getSecureData() map[string]string {
+	if r.securePrefix == "" {
+		return r.secureData
+	}
+	secureData := map[string]string{}
+	for key, value := range r.secureData {
+		if strings.HasPrefix(key, r.securePrefix) {
+			secureData[strings.TrimPrefix(key, r.securePrefix)] = value
+		}
+	}
+	return secureData
+}
+
+// getSecure returns the secure value, recording the given message as error if the value is missing or empty
+func (r *dataReader) getSecure(key string, message string) string {
+	value, ok := r.lookupSecure(key)
+	if !ok || value == "" {
+		r.addSecureFieldError(key, message)
+	}
+	return value
+}
+
+// getField reads the string field of the built-in authentication type as defined by its descriptor,
+// secure fields are read from the secure data
+func (r *dataReader) getField(authType string, name string) string {
+	field := mustGetBuiltInField(authType, name)
+
+	if field.Secure {
+		if field.Required {
+			return r.getSecure(field.Name, fmt.Sprintf("no %s provided", field.label))
+		}
+		value, _ := r.lookupSecure(field.Name)
+		return value
+	}
+
+	var value string
+	if field.Required {
+		value = r.getString(field.Name)
+	} else {
+		value = r.getStringOptional(field.Name)
+	}
+	if value != "" && !field.isAllowed(value) {
+		r.addFieldError(field.Name, fmt.Sprintf("invalid %s provided", field.label))
+	}
+	return value
+}
+
+// getBoolField reads the boolean field of the built-in authentication type, boolean fields are optional
+func (r *dataReader) getBoolField(authType string, name string) bool {
+	field := mustGetBuiltInField(authType, name)
+	return r.getBoolOptional(field.Name)
+}
@@ -0,0 +1,261 @@
+package azcredentials
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/internal/redact"
+)
+
+// The credentials are formatted with their secrets redacted (see internal/redact).
+
+func (credentials AadCurrentUserCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "ServiceCredentialsEnabled", Value: credentials.ServiceCredentialsEnabled},
+		{Name: "ServiceCredentials", Value: credentials.ServiceCredentials},
+	}
+}
+
+func (credentials AadCurrentUserCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AadCurrentUserCredentials) GoString() string {
+	return redact.GoString("azcredentials.AadCurrentUserCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AadCurrentUserCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureManagedIdentityCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "ClientId", Value: credentials.ClientId},
+		{Name: "ObjectId", Value: credentials.ObjectId},
+		{Name: "ResourceId", Value: credentials.ResourceId},
+	}
+}
+
+func (credentials AzureManagedIdentityCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureManagedIdentityCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureManagedIdentityCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureManagedIdentityCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureWorkloadIdentityCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureCloud", Value: credentials.AzureCloud},
+		{Name: "ClientId", Value: credentials.ClientId},
+		{Name: "TenantId", Value: credentials.TenantId},
+	}
+}
+
+func (credentials AzureWorkloadIdentityCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureWorkloadIdentityCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureWorkloadIdentityCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureWorkloadIdentityCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureCloud", Value: credentials.AzureCloud},
+		{Name: "Authority", Value: credentials.Authority},
+		{Name: "TenantId", Value: credentials.TenantId},
+		{Name: "ClientId", Value: credentials.ClientId},
+		redact.Secret("ClientSecret", credentials.ClientSecret),
+		redact.Secret("ClientSecretSecondary", credentials.ClientSecretSecondary),
+	}
+}
+
+func (credentials AzureClientSecretCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureClientSecretCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientCertificateCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureCloud", Value: credentials.AzureCloud},
+		{Name: "Authority", Value: credentials.Authority},
+		{Name: "TenantId", Value: credentials.TenantId},
+		{Name: "ClientId", Value: credentials.ClientId},
+		{Name: "CertificateFormat", Value: credentials.CertificateFormat},
+		redact.Secret("ClientCertificate", credentials.ClientCertificate),
+		redact.Secret("PrivateKey", credentials.PrivateKey),
+		redact.Secret("CertificatePassword", credentials.CertificatePassword),
+		{Name: "SendCertificateChain", Value: credentials.SendCertificateChain},
+	}
+}
+
+func (credentials AzureClientCertificateCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientCertificateCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureClientCertificateCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureClientCertificateCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureEntraPasswordCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureCloud", Value: credentials.AzureCloud},
+		redact.Secret("Password", credentials.Password),
+		{Name: "UserId", Value: credentials.UserId},
+		{Name: "ClientId", Value: credentials.ClientId},
+		{Name: "TenantId", Value: credentials.TenantId},
+	}
+}
+
+func (credentials AzureEntraPasswordCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureEntraPasswordCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureEntraPasswordCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureEntraPasswordCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretOboCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "ClientSecretCredentials", Value: credentials.ClientSecretCredentials},
+	}
+}
+
+func (credentials AzureClientSecretOboCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretOboCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureClientSecretOboCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureClientSecretOboCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+// The assertion callback isn't formatted, it's neither a secret nor meaningful when printed
+func (credentials AzureClientAssertionCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureCloud", Value: credentials.AzureCloud},
+		{Name: "TenantId", Value: credentials.TenantId},
+		{Name: "ClientId", Value: credentials.ClientId},
+		{Name: "AssertionFile", Value: credentials.AssertionFile},
+		{Name: "AssertionKey", Value: credentials.AssertionKey},
+	}
+}
+
+func (credentials AzureClientAssertionCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureClientAssertionCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureClientAssertionCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureClientAssertionCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureApiKeyCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "HeaderName", Value: credentials.HeaderName},
+		redact.Secret("ApiKey", credentials.ApiKey),
+	}
+}
+
+func (credentials AzureApiKeyCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureApiKeyCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureApiKeyCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureApiKeyCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSharedKeyCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AccountName", Value: credentials.AccountName},
+		redact.Secret("AccountKey", credentials.AccountKey),
+	}
+}
+
+func (credentials AzureStorageSharedKeyCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSharedKeyCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureStorageSharedKeyCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSharedKeyCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSasCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		redact.Secret("SasToken", credentials.SasToken),
+	}
+}
+
+func (credentials AzureStorageSasCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSasCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureStorageSasCredentials", credentials.redactedFields()...)
+}
+
+func (credentials AzureStorageSasCredentials) LogValue() slog.Value {
+	return redact.LogValue(credentials.redactedFields()...)
+}
+
+func (credentials AzureChainedCredentials) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "Credentials", Value: credentials.Credentials},
+	}
+}
+
+func (credentials AzureChainedCredentials) String() string {
+	return redact.String(credentials.redactedFields()...)
+}
+
+func (credentials AzureChainedCredentials) GoString() string {
+	return redact.GoString("azcredentials.AzureChainedCredentials", credentials.redactedFields()...)
+}
+
+// LogValue logs the chained credentials as a group of groups keyed by their index, as handlers don't resolve
+// the values of a slice
+func (credentials AzureChainedCredentials) LogValue() slog.Value {
+	chainedFields := make([]redact.Field, 0, len(credentials.Credentials))
+	for i, chainedCredentials := range credentials.Credentials {
+		chainedFields = append(chainedFields, redact.Field{Name: strconv.Itoa(i), Value: chainedCredentials})
+	}
+	return redact.LogValue(redact.Field{Name: "Credentials", Value: redact.LogValue(chainedFields...)})
+}
@@ -0,0 +1,77 @@
+package azcredentials
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CredentialsParser parses credentials of a custom authentication type from the `azureCredentials` object
+// of the datasource jsonData and the datasource secureJsonData.
+type CredentialsParser = func(credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, error)
+
+var (
+	customParsersMu sync.RWMutex
+	customParsers   = map[string]CredentialsParser{}
+
+	customDescriptorsMu sync.RWMutex
+	customDescriptors   = map[string]*CredentialsDescriptor{}
+)
+
+// RegisterCredentialsParser registers a parser for the given authentication type which will be used
+// by FromDatasourceData. A parser registered for a built-in authentication type takes precedence over
+// the built-in parser.
+func RegisterCredentialsParser(authType string, parser CredentialsParser) error {
+	if parser == nil {
+		return fmt.Errorf("parameter 'parser' cannot be nil")
+	}
+	if authType == "" {
+		return fmt.Errorf("authentication type of the parser must be set")
+	}
+
+	customParsersMu.Lock()
+	defer customParsersMu.Unlock()
+	customParsers[authType] = parser
+	return nil
+}
+
+// UnregisterCredentialsParser removes the parser registered for the given authentication type, a built-in
+// parser of the authentication type is used again after that.
+func UnregisterCredentialsParser(authType string) {
+	customParsersMu.Lock()
+	defer customParsersMu.Unlock()
+	delete(customParsers, authType)
+}
+
+func getCustomParser(authType string) (CredentialsParser, bool) {
+	customParsersMu.RLock()
+	defer customParsersMu.RUnlock()
+	parser, ok := customParsers[authType]
+	return parser, ok
+}
+
+// RegisterCredentialsDescriptor registers the descriptor of a custom authentication type which will be
+// returned by GetCredentialsDescriptor and GetCredentialsDescriptors. Descriptors of built-in authentication
+// types cannot be replaced, as they define the fields read by FromDatasourceData.
+func RegisterCredentialsDescriptor(descriptor *CredentialsDescriptor) error {
+	if descriptor == nil {
+		return fmt.Errorf("parameter 'descriptor' cannot be nil")
+	}
+	if descriptor.AuthType == "" {
+		return fmt.Errorf("authentication type of the descriptor must be set")
+	}
+	if _, ok := getBuiltInDescriptor(descriptor.AuthType); ok {
+		return fmt.Errorf("descriptor of the built-in authentication type '%s' cannot be replaced", descriptor.AuthType)
+	}
+
+	customDescriptorsMu.Lock()
+	defer customDescriptorsMu.Unlock()
+	customDescriptors[descriptor.AuthType] = descriptor.clone()
+	return nil
+}
+
+func getCustomDescriptor(authType string) (*CredentialsDescriptor, bool) {
+	customDescriptorsMu.RLock()
+	defer customDescriptorsMu.RUnlock()
+	descriptor, ok := customDescriptors[authType]
+	return descriptor, ok
+}
@@ -0,0 +1,188 @@
+package azcredentials
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customCredentials struct {
+	Endpoint string
+	ApiKey   string
+}
+
+func (credentials *customCredentials) AzureAuthType() string {
+	return "custom"
+}
+
+func parseCustomCredentials(credentialsObj map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+	endpoint, err := maputil.GetString(credentialsObj, "endpoint")
+	if err != nil {
+		return nil, err
+	}
+	return &customCredentials{
+		Endpoint: endpoint,
+		ApiKey:   secureData["apiKey"],
+	}, nil
+}
+
+func TestRegisterCredentialsParser(t *testing.T) {
+	t.Run("should parse custom credentials with registered parser", func(t *testing.T) {
+		err := RegisterCredentialsParser("custom", parseCustomCredentials)
+		require.NoError(t, err)
+		t.Cleanup(func() { UnregisterCredentialsParser("custom") })
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom",
+				"endpoint": "https://custom.example.org",
+			},
+		}
+		var secureData = map[string]string{
+			"apiKey": "FAKE-API-KEY",
+		}
+
+		result, err := FromDatasourceData(data, secureData)
+		require.NoError(t, err)
+
+		require.IsType(t, &customCredentials{}, result)
+		credential := result.(*customCredentials)
+		assert.Equal(t, "https://custom.example.org", credential.Endpoint)
+		assert.Equal(t, "FAKE-API-KEY", credential.ApiKey)
+	})
+
+	t.Run("should return parser error", func(t *testing.T) {
+		err := RegisterCredentialsParser("custom", parseCustomCredentials)
+		require.NoError(t, err)
+		t.Cleanup(func() { UnregisterCredentialsParser("custom") })
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom",
+			},
+		}
+
+		_, err = FromDatasourceData(data, map[string]string{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should parse custom service credentials of current user credentials", func(t *testing.T) {
+		err := RegisterCredentialsParser("custom", parseCustomCredentials)
+		require.NoError(t, err)
+		t.Cleanup(func() { UnregisterCredentialsParser("custom") })
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":                  "currentuser",
+				"serviceCredentialsEnabled": true,
+				"serviceCredentials": map[string]interface{}{
+					"authType": "custom",
+					"endpoint": "https://custom.example.org",
+				},
+			},
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		require.IsType(t, &AadCurrentUserCredentials{}, result)
+		assert.IsType(t, &customCredentials{}, result.(*AadCurrentUserCredentials).ServiceCredentials)
+	})
+
+	t.Run("should use registered parser for built-in authentication type", func(t *testing.T) {
+		err := RegisterCredentialsParser(AzureAuthManagedIdentity, func(_ map[string]interface{}, _ map[string]string) (AzureCredentials, error) {
+			return &AzureManagedIdentityCredentials{ClientId: "CUSTOM-CLIENT-ID"}, nil
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { UnregisterCredentialsParser(AzureAuthManagedIdentity) })
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+		}
+
+		result, err := FromDatasourceData(data, map[string]string{})
+		require.NoError(t, err)
+
+		require.IsType(t, &AzureManagedIdentityCredentials{}, result)
+		assert.Equal(t, "CUSTOM-CLIENT-ID", result.(*AzureManagedIdentityCredentials).ClientId)
+	})
+
+	t.Run("should not parse custom credentials after parser unregistered", func(t *testing.T) {
+		err := RegisterCredentialsParser("custom", parseCustomCredentials)
+		require.NoError(t, err)
+		UnregisterCredentialsParser("custom")
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom",
+				"endpoint": "https://custom.example.org",
+			},
+		}
+
+		_, err = FromDatasourceData(data, map[string]string{})
+		assert.ErrorContains(t, err, "the authentication type 'custom' not supported")
+	})
+
+	t.Run("should return error if parser not valid", func(t *testing.T) {
+		assert.Error(t, RegisterCredentialsParser("custom", nil))
+		assert.Error(t, RegisterCredentialsParser("", parseCustomCredentials))
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom",
+			},
+		}
+
+		_, err := FromDatasourceData(data, map[string]string{})
+		assert.ErrorContains(t, err, "the authentication type 'custom' not supported")
+	})
+}
+
+func unregisterCredentialsDescriptor(authType string) {
+	customDescriptorsMu.Lock()
+	defer customDescriptorsMu.Unlock()
+	delete(customDescriptors, authType)
+}
+
+func TestRegisterCredentialsDescriptor(t *testing.T) {
+	customDescriptor := &CredentialsDescriptor{
+		AuthType:    "custom",
+		DisplayName: "Custom",
+		Fields: []FieldDescriptor{
+			{Name: "endpoint", Type: FieldTypeString, Required: true},
+			{Name: "apiKey", Type: FieldTypeString, Secure: true, Required: true},
+		},
+	}
+
+	t.Run("should return registered descriptor of custom credentials", func(t *testing.T) {
+		err := RegisterCredentialsDescriptor(customDescriptor)
+		require.NoError(t, err)
+		t.Cleanup(func() { unregisterCredentialsDescriptor("custom") })
+
+		descriptor, ok := GetCredentialsDescriptor("custom")
+		require.True(t, ok)
+		assert.Equal(t, customDescriptor, descriptor)
+
+		descriptors := GetCredentialsDescriptors()
+		assert.Equal(t, customDescriptor, descriptors[len(descriptors)-1])
+	})
+
+	t.Run("should return error if descriptor of built-in authentication type registered", func(t *testing.T) {
+		overrideDescriptor := &CredentialsDescriptor{AuthType: AzureAuthClientSecret, DisplayName: "Custom App Registration"}
+		err := RegisterCredentialsDescriptor(overrideDescriptor)
+		assert.EqualError(t, err, "descriptor of the built-in authentication type 'clientsecret' cannot be replaced")
+
+		descriptor, ok := GetCredentialsDescriptor(AzureAuthClientSecret)
+		require.True(t, ok)
+		assert.NotEqual(t, "Custom App Registration", descriptor.DisplayName)
+	})
+
+	t.Run("should return error if descriptor not valid", func(t *testing.T) {
+		assert.Error(t, RegisterCredentialsDescriptor(nil))
+		assert.Error(t, RegisterCredentialsDescriptor(&CredentialsDescriptor{}))
+	})
+}
@@ -0,0 +1,150 @@
+package azcredentials
+
+import (
+	"fmt"
+)
+
+// ToDatasourceData serializes the given credentials into the datasource jsonData object (with the credentials
+// stored under the `azureCredentials` key) and the secureJsonData map.
+//
+// The result can be read back with FromDatasourceData, which returns credentials equal to the given ones
+// for all credentials which can be produced by FromDatasourceData. The service credentials of
+// AadCurrentUserCredentials are stored only if they are enabled, as FromDatasourceData ignores them otherwise.
+// The secure fields of chained credentials are stored with the prefix of their index in the chain, e.g.
+// `credentials.0.azureClientSecret`, so that the secrets of the chained credentials don't overwrite each other.
+func ToDatasourceData(credentials AzureCredentials) (map[string]interface{}, map[string]string, error) {
+	if credentials == nil {
+		return nil, nil, fmt.Errorf("parameter 'credentials' cannot be nil")
+	}
+
+	secureData := map[string]string{}
+	credentialsObj, err := getCredentialsObject(credentials, secureData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := map[string]interface{}{
+		"azureCredentials": credentialsObj,
+	}
+	return data, secureData, nil
+}
+
+func getCredentialsObject(credentials AzureCredentials, secureData map[string]string) (map[string]interface{}, error) {
+	credentialsObj := map[string]interface{}{
+		"authType": credentials.AzureAuthType(),
+	}
+
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		credentialsObj["serviceCredentialsEnabled"] = c.ServiceCredentialsEnabled
+		if c.ServiceCredentialsEnabled && c.ServiceCredentials != nil {
+			serviceCredentialsObj, err := getCredentialsObject(c.ServiceCredentials, secureData)
+			if err != nil {
+				return nil, err
+			}
+			credentialsObj["serviceCredentials"] = serviceCredentialsObj
+		}
+
+	case *AzureManagedIdentityCredentials:
+		setStringOptional(credentialsObj, "clientId", c.ClientId)
+		setStringOptional(credentialsObj, "objectId", c.ObjectId)
+		setStringOptional(credentialsObj, "resourceId", c.ResourceId)
+
+	case *AzureWorkloadIdentityCredentials:
+		setStringOptional(credentialsObj, "azureCloud", c.AzureCloud)
+		setStringOptional(credentialsObj, "tenantId", c.TenantId)
+		setStringOptional(credentialsObj, "clientId", c.ClientId)
+
+	case *AzureClientSecretCredentials:
+		setClientSecretCredentials(credentialsObj, secureData, c)
+
+	case *AzureClientCertificateCredentials:
+		credentialsObj["azureCloud"] = c.AzureCloud
+		setStringOptional(credentialsObj, "authority", c.Authority)
+		credentialsObj["tenantId"] = c.TenantId
+		credentialsObj["clientId"] = c.ClientId
+		setStringOptional(credentialsObj, "certificateFormat", c.CertificateFormat)
+		if c.SendCertificateChain {
+			credentialsObj["sendCertificateChain"] = true
+		}
+		secureData["clientCertificate"] = c.ClientCertificate
+		if c.PrivateKey != "" {
+			secureData["privateKey"] = c.PrivateKey
+		}
+		if c.CertificatePassword != "" {
+			secureData["certificatePassword"] = c.CertificatePassword
+		}
+
+	case *AzureClientAssertionCredentials:
+		if c.GetAssertion != nil {
+			err := fmt.Errorf("client assertion callback cannot be stored in the datasource data")
+			return nil, err
+		}
+		credentialsObj["azureCloud"] = c.AzureCloud
+		credentialsObj["tenantId"] = c.TenantId
+		credentialsObj["clientId"] = c.ClientId
+		credentialsObj["assertionFile"] = c.AssertionFile
+
+	case *AzureChainedCredentials:
+		chainedCredentialsObjs := make([]interface{}, 0, len(c.Credentials))
+		for i, chainedCredentials := range c.Credentials {
+			chainedSecureData := map[string]string{}
+			chainedCredentialsObj, err := getCredentialsObject(chainedCredentials, chainedSecureData)
+			if err != nil {
+				return nil, err
+			}
+			chainedCredentialsObjs = append(chainedCredentialsObjs, chainedCredentialsObj)
+
+			// Secure fields of each chained credentials are stored under their own prefix
+			securePrefix := getItemSecurePrefix("", "credentials", i)
+			for key, value := range chainedSecureData {
+				secureData[securePrefix+key] = value
+			}
+		}
+		credentialsObj["credentials"] = chainedCredentialsObjs
+
+	case *AzureClientSecretOboCredentials:
+		setClientSecretCredentials(credentialsObj, secureData, &c.ClientSecretCredentials)
+
+	case *AzureEntraPasswordCredentials:
+		setStringOptional(credentialsObj, "azureCloud", c.AzureCloud)
+		setStringOptional(credentialsObj, "tenantId", c.TenantId)
+		credentialsObj["clientId"] = c.ClientId
+		credentialsObj["userId"] = c.UserId
+		secureData["password"] = c.Password
+
+	case *AzureApiKeyCredentials:
+		credentialsObj["headerName"] = c.HeaderName
+		secureData["apiKey"] = c.ApiKey
+
+	case *AzureStorageSharedKeyCredentials:
+		credentialsObj["accountName"] = c.AccountName
+		secureData["accountKey"] = c.AccountKey
+
+	case *AzureStorageSasCredentials:
+		secureData["sasToken"] = c.SasToken
+
+	default:
+		err := fmt.Errorf("the authentication type '%s' not supported", credentials.AzureAuthType())
+		return nil, err
+	}
+
+	return credentialsObj, nil
+}
+
+func setClientSecretCredentials(credentialsObj map[string]interface{}, secureData map[string]string, c *AzureClientSecretCredentials) {
+	credentialsObj["azureCloud"] = c.AzureCloud
+	setStringOptional(credentialsObj, "authority", c.Authority)
+	credentialsObj["tenantId"] = c.TenantId
+	credentialsObj["clientId"] = c.ClientId
+	secureData["azureClientSecret"] = c.ClientSecret
+	if c.ClientSecretSecondary != "" {
+		secureData["azureClientSecretSecondary"] = c.ClientSecretSecondary
+	}
+}
+
+func setStringOptional(obj map[string]interface{}, key string, value string) {
+	if value != "" {
+		obj[key] = value
+	}
+}
@@ -0,0 +1,388 @@
+package azcredentials
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"golang.org/x/net/http/httpguts"
+)
+
+// FieldError describes a problem with a single field of the datasource configuration.
+type FieldError struct {
+	// JSON path of the field, e.g. `azureCredentials.tenantId` or `secureJsonData.clientCertificate`
+	Path    string
+	Message string
+
+	err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// ValidationError lists all the problems found in the credentials configuration.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid Azure credentials: %s", strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		errs = append(errs, fieldErr)
+	}
+	return errs
+}
+
+// ValidatableCredentials is implemented by credentials which can check their configuration
+// against the Azure settings of the Grafana instance.
+type ValidatableCredentials interface {
+	AzureCredentials
+	Validate(settings *azsettings.AzureSettings) error
+}
+
+// FromDatasourceDataWithValidation reads the credentials like FromDatasourceData and validates them
+// against the given settings. All the problems found are returned as *ValidationError.
+func FromDatasourceDataWithValidation(settings *azsettings.AzureSettings, data map[string]interface{}, secureData map[string]string) (AzureCredentials, error) {
+	if settings == nil {
+		return nil, fmt.Errorf("parameter 'settings' cannot be nil")
+	}
+
+	credentials, errs := parseDatasourceData(data, secureData)
+	if credentials != nil {
+		// Skip problems of the fields which already failed to be read
+		for _, fieldErr := range validateCredentials(settings, credentials, credentialsPath, "") {
+			if !hasFieldError(errs, fieldErr.Path) {
+				errs = append(errs, fieldErr)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	return credentials, nil
+}
+
+// ValidateCredentials validates the given credentials against the settings, credentials of custom
+// authentication types are validated only if they implement ValidatableCredentials.
+func ValidateCredentials(settings *azsettings.AzureSettings, credentials AzureCredentials) error {
+	if settings == nil {
+		return fmt.Errorf("parameter 'settings' cannot be nil")
+	}
+	if credentials == nil {
+		return fmt.Errorf("parameter 'credentials' cannot be nil")
+	}
+
+	return toValidationError(validateCredentials(settings, credentials, credentialsPath, ""))
+}
+
+func (credentials *AadCurrentUserCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureManagedIdentityCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureWorkloadIdentityCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureClientSecretCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureClientCertificateCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureClientSecretOboCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureEntraPasswordCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureClientAssertionCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureChainedCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureApiKeyCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureStorageSharedKeyCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func (credentials *AzureStorageSasCredentials) Validate(settings *azsettings.AzureSettings) error {
+	return ValidateCredentials(settings, credentials)
+}
+
+func validateCredentials(settings *azsettings.AzureSettings, credentials AzureCredentials, path string, securePrefix string) []*FieldError {
+	v := &validator{settings: settings, path: path, securePrefix: securePrefix}
+
+	switch c := credentials.(type) {
+	case *AadCurrentUserCredentials:
+		v.requireAvailable(c.AzureAuthType())
+		if c.ServiceCredentialsEnabled {
+			if c.ServiceCredentials == nil {
+				v.addError("serviceCredentials", "service credentials must be set when enabled")
+			} else {
+				fallbackType := c.ServiceCredentials.AzureAuthType()
+				if fallbackType == AzureAuthCurrentUserIdentity || fallbackType == AzureAuthClientSecretObo {
+					v.addError("serviceCredentials.authType", "user identity authentication not valid for fallback credentials")
+				} else if IsKeyCredentials(c.ServiceCredentials) {
+					v.addError("serviceCredentials.authType", fmt.Sprintf("the authentication type '%s' not valid for fallback credentials", fallbackType))
+				} else {
+					v.errs = append(v.errs, validateCredentials(settings, c.ServiceCredentials, v.fieldPath("serviceCredentials"), v.securePrefix)...)
+				}
+			}
+		}
+
+	case *AzureManagedIdentityCredentials:
+		v.requireAvailable(c.AzureAuthType())
+		if key, ok := getManagedIdentityConflict(c); !ok {
+			v.addError(key, managedIdentityConflictMessage)
+		}
+		if !settings.IsManagedIdentityAllowed(c.ClientId) {
+			v.addError("clientId", fmt.Sprintf("managed identity with client ID '%s' is not allowed in Grafana config", c.ClientId))
+		}
+		if !settings.IsManagedIdentityAllowed(c.ObjectId) {
+			v.addError("objectId", fmt.Sprintf("managed identity with object ID '%s' is not allowed in Grafana config", c.ObjectId))
+		}
+		if !settings.IsManagedIdentityAllowed(c.ResourceId) {
+			v.addError("resourceId", fmt.Sprintf("managed identity with resource ID '%s' is not allowed in Grafana config", c.ResourceId))
+		}
+
+	case *AzureWorkloadIdentityCredentials:
+		v.requireAvailable(c.AzureAuthType())
+		if !settings.IsWorkloadIdentityAllowed(c.ClientId) {
+			v.addError("clientId", fmt.Sprintf("workload identity with client ID '%s' is not allowed in Grafana config", c.ClientId))
+		}
+		if c.AzureCloud != "" {
+			v.requireCloud(c.AzureCloud)
+			if !settings.IsWorkloadIdentityCloudAllowed(c.AzureCloud) {
+				v.addError("azureCloud", fmt.Sprintf("workload identity in the Azure cloud '%s' is not allowed in Grafana config", c.AzureCloud))
+			}
+		}
+
+	case *AzureClientSecretCredentials:
+		v.validateClientSecret(c)
+
+	case *AzureClientCertificateCredentials:
+		v.requireCloud(c.AzureCloud)
+		v.requireAuthorityOfCloud(c.AzureCloud, c.Authority)
+		v.require("tenantId", c.TenantId, "tenant ID must be set")
+		v.require("clientId", c.ClientId, "client ID must be set")
+		if !isCertificateFormat(c.CertificateFormat) {
+			v.addError("certificateFormat", "invalid certificate format provided")
+		} else {
+			v.requireSecure("clientCertificate", c.ClientCertificate, "no certificate provided")
+			if isPrivateKeyRequired(c) {
+				v.requireSecure("privateKey", c.PrivateKey, "no private key provided")
+			}
+		}
+
+	case *AzureClientSecretOboCredentials:
+		v.validateClientSecret(&c.ClientSecretCredentials)
+
+	case *AzureEntraPasswordCredentials:
+		v.requireAvailable(c.AzureAuthType())
+		if c.AzureCloud != "" {
+			v.requireCloud(c.AzureCloud)
+		}
+		v.require("userId", c.UserId, "user ID must be set")
+		v.require("clientId", c.ClientId, "client ID must be set")
+		v.requireSecure("password", c.Password, "no password provided")
+
+	case *AzureClientAssertionCredentials:
+		if c.GetAssertion == nil {
+			// Reading the assertion from a file on the Grafana instance must be allowed by the admin
+			v.requireAvailable(c.AzureAuthType())
+			if c.AssertionFile == "" {
+				v.addError("assertionFile", "no client assertion file provided")
+			} else if !v.settings.IsClientAssertionFileAllowed(c.AssertionFile) {
+				v.addError("assertionFile", fmt.Sprintf("client assertion file '%s' not allowed in Grafana config", c.AssertionFile))
+			}
+		} else {
+			v.require("assertionKey", c.AssertionKey, "client assertion key must be set for client assertion callback")
+		}
+		v.requireCloud(c.AzureCloud)
+		v.require("tenantId", c.TenantId, "tenant ID must be set")
+		v.require("clientId", c.ClientId, "client ID must be set")
+
+	case *AzureApiKeyCredentials:
+		if c.HeaderName == "" {
+			v.addError("headerName", "header name must be set")
+		} else if !httpguts.ValidHeaderFieldName(c.HeaderName) {
+			v.addError("headerName", fmt.Sprintf("invalid header name '%s'", c.HeaderName))
+		}
+		v.requireSecure("apiKey", c.ApiKey, "no API key provided")
+
+	case *AzureStorageSharedKeyCredentials:
+		v.require("accountName", c.AccountName, "storage account name must be set")
+		if c.AccountKey == "" {
+			v.requireSecure("accountKey", c.AccountKey, "no account key provided")
+		} else if _, err := base64.StdEncoding.DecodeString(c.AccountKey); err != nil {
+			v.errs = append(v.errs, &FieldError{Path: v.secureFieldPath("accountKey"), Message: "account key must be base64 encoded"})
+		}
+
+	case *AzureStorageSasCredentials:
+		if c.SasToken == "" {
+			v.requireSecure("sasToken", c.SasToken, "no SAS token provided")
+		} else if query, err := url.ParseQuery(strings.TrimPrefix(c.SasToken, "?")); err != nil || !query.Has("sig") {
+			v.errs = append(v.errs, &FieldError{Path: v.secureFieldPath("sasToken"), Message: "SAS token must be a query string with a signature"})
+		}
+
+	case *AzureChainedCredentials:
+		v.validateChained(c)
+
+	case ValidatableCredentials:
+		if err := c.Validate(settings); err != nil {
+			v.errs = append(v.errs, toFieldErrors(err, path)...)
+		}
+	}
+
+	return v.errs
+}
+
+type validator struct {
+	settings     *azsettings.AzureSettings
+	path         string
+	securePrefix string
+	errs         []*FieldError
+}
+
+func (v *validator) fieldPath(key string) string {
+	return v.path + "." + key
+}
+
+func (v *validator) secureFieldPath(key string) string {
+	return secureDataPath + "." + v.securePrefix + key
+}
+
+func (v *validator) addError(key string, message string) {
+	v.errs = append(v.errs, &FieldError{Path: v.fieldPath(key), Message: message})
+}
+
+func (v *validator) require(key string, value string, message string) {
+	if value == "" {
+		v.addError(key, message)
+	}
+}
+
+func (v *validator) requireSecure(key string, value string, message string) {
+	if value == "" {
+		v.errs = append(v.errs, &FieldError{Path: v.secureFieldPath(key), Message: message})
+	}
+}
+
+// requireAvailable checks that the authentication type is available in Grafana config, whether the datasource
+// supports user identity isn't known when validating the credentials
+func (v *validator) requireAvailable(authType string) {
+	if err := getAuthTypeUnavailableError(v.settings, authType, AuthTypeOptions{UserIdentitySupported: true}); err != nil {
+		v.addError("authType", err.Error())
+	}
+}
+
+func (v *validator) requireCloud(cloudName string) {
+	if cloudName == "" {
+		v.addError("azureCloud", "Azure cloud must be set")
+	} else if _, err := v.settings.GetCloud(cloudName); err != nil {
+		v.addError("azureCloud", err.Error())
+	}
+}
+
+// requireAuthorityOfCloud checks that the custom authority doesn't belong to a cloud other than the configured one
+func (v *validator) requireAuthorityOfCloud(cloudName string, authority string) {
+	if _, err := resolveAzureCloud(v.settings, cloudName, authority); err != nil {
+		v.addError("authority", err.Error())
+	}
+}
+
+func (v *validator) validateClientSecret(c *AzureClientSecretCredentials) {
+	v.requireCloud(c.AzureCloud)
+	v.requireAuthorityOfCloud(c.AzureCloud, c.Authority)
+	v.require("tenantId", c.TenantId, "tenant ID must be set")
+	v.require("clientId", c.ClientId, "client ID must be set")
+	v.requireSecure("azureClientSecret", c.ClientSecret, "no client secret provided")
+}
+
+func (v *validator) validateChained(c *AzureChainedCredentials) {
+	if len(c.Credentials) == 0 {
+		v.addError("credentials", "at least one credentials must be set")
+		return
+	}
+
+	// Credentials of an authentication type which isn't enabled in this Grafana instance are skipped
+	// by the chain, so they are only reported if there are no usable credentials in the chain
+	var disabledErrs []*FieldError
+	usable := false
+	for i, chainedCredentials := range c.Credentials {
+		chainedPath := fmt.Sprintf("%s[%d]", v.fieldPath("credentials"), i)
+		if chainedCredentials == nil {
+			v.errs = append(v.errs, &FieldError{Path: chainedPath, Message: "credentials must be set"})
+			continue
+		}
+		if !IsChainableCredentials(chainedCredentials) {
+			v.errs = append(v.errs, &FieldError{Path: chainedPath + ".authType", Message: fmt.Sprintf("the authentication type '%s' cannot be chained", chainedCredentials.AzureAuthType())})
+			continue
+		}
+
+		errs := validateCredentials(v.settings, chainedCredentials, chainedPath, getItemSecurePrefix(v.securePrefix, "credentials", i))
+		if len(errs) == 0 {
+			usable = true
+		}
+		for _, fieldErr := range errs {
+			if fieldErr.Path == chainedPath+".authType" {
+				disabledErrs = append(disabledErrs, fieldErr)
+			} else {
+				v.errs = append(v.errs, fieldErr)
+			}
+		}
+	}
+
+	if !usable {
+		v.errs = append(v.errs, disabledErrs...)
+	}
+}
+
+func hasFieldError(errs []*FieldError, path string) bool {
+	for _, fieldErr := range errs {
+		if fieldErr.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func toValidationError(errs []*FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
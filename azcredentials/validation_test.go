@@ -0,0 +1,481 @@
+package azcredentials
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldErrorPaths(t *testing.T, err error) []string {
+	t.Helper()
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	paths := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		paths = append(paths, fieldErr.Path)
+	}
+	return paths
+}
+
+func TestFromDatasourceDataWithValidation(t *testing.T) {
+	settings := &azsettings.AzureSettings{}
+
+	t.Run("should return nil when no credentials configured", func(t *testing.T) {
+		result, err := FromDatasourceDataWithValidation(settings, map[string]interface{}{}, map[string]string{})
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("should return valid client secret credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "AzureCloud",
+				"tenantId":   "TENANT-ID",
+				"clientId":   "CLIENT-ID",
+			},
+		}
+		var secureData = map[string]string{
+			"azureClientSecret": "FAKE-SECRET",
+		}
+
+		result, err := FromDatasourceDataWithValidation(settings, data, secureData)
+		require.NoError(t, err)
+		assert.IsType(t, &AzureClientSecretCredentials{}, result)
+	})
+
+	t.Run("should return error for invalid credentials object", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": "invalid",
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{"azureCredentials"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error for unsupported authentication type", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "invalid",
+			},
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{"azureCredentials.authType"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return all problems of client secret credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":   "clientsecret",
+				"azureCloud": "UnknownCloud",
+				"clientId":   42,
+			},
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{
+			"azureCredentials.tenantId",
+			"azureCredentials.clientId",
+			"azureCredentials.azureCloud",
+			"secureJsonData.azureClientSecret",
+		}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return all problems of client certificate credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":          "clientcertificate",
+				"azureCloud":        "AzureCloud",
+				"tenantId":          "",
+				"clientId":          "CLIENT-ID",
+				"certificateFormat": "pem",
+			},
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{
+			"secureJsonData.clientCertificate",
+			"secureJsonData.privateKey",
+			"azureCredentials.tenantId",
+		}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return problems of nested service credentials", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType":                  "currentuser",
+				"serviceCredentialsEnabled": true,
+				"serviceCredentials": map[string]interface{}{
+					"authType": "clientsecret",
+				},
+			},
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{
+			"azureCredentials.serviceCredentials.azureCloud",
+			"azureCredentials.serviceCredentials.tenantId",
+			"azureCredentials.serviceCredentials.clientId",
+			"azureCredentials.authType",
+			"secureJsonData.azureClientSecret",
+		}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if authentication type is disabled in settings", func(t *testing.T) {
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "msi",
+			},
+		}
+
+		_, err := FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{"azureCredentials.authType"}, fieldErrorPaths(t, err))
+		assert.ErrorContains(t, err, "managed identity authentication is not enabled in Grafana config")
+
+		result, err := FromDatasourceDataWithValidation(&azsettings.AzureSettings{ManagedIdentityEnabled: true}, data, map[string]string{})
+		require.NoError(t, err)
+		assert.IsType(t, &AzureManagedIdentityCredentials{}, result)
+	})
+
+	t.Run("should keep path of errors returned by custom parser", func(t *testing.T) {
+		err := RegisterCredentialsParser("custom", parseCustomCredentials)
+		require.NoError(t, err)
+		t.Cleanup(func() { UnregisterCredentialsParser("custom") })
+
+		var data = map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": "custom",
+			},
+		}
+
+		_, err = FromDatasourceDataWithValidation(settings, data, map[string]string{})
+		assert.Equal(t, []string{"azureCredentials"}, fieldErrorPaths(t, err))
+	})
+}
+
+func TestValidateCredentials(t *testing.T) {
+	settings := &azsettings.AzureSettings{
+		CustomCloudList: []*azsettings.AzureCloudSettings{
+			{
+				Name:         "CustomCloud",
+				AadAuthority: "https://login.contoso.com/",
+			},
+		},
+	}
+
+	t.Run("should accept valid credentials", func(t *testing.T) {
+		credentials := &AzureClientSecretCredentials{
+			AzureCloud:   "CustomCloud",
+			TenantId:     "TENANT-ID",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-SECRET",
+		}
+
+		err := credentials.Validate(settings)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error for unknown cloud", func(t *testing.T) {
+		credentials := &AzureClientSecretOboCredentials{
+			ClientSecretCredentials: AzureClientSecretCredentials{
+				AzureCloud:   "UnknownCloud",
+				TenantId:     "TENANT-ID",
+				ClientId:     "CLIENT-ID",
+				ClientSecret: "FAKE-SECRET",
+			},
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.azureCloud"}, fieldErrorPaths(t, err))
+		assert.ErrorContains(t, err, "the Azure cloud 'UnknownCloud' is not supported")
+	})
+
+	t.Run("should return error for workload identity in other cloud if cross-cloud not enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			WorkloadIdentityEnabled: true,
+		}
+		credentials := &AzureWorkloadIdentityCredentials{AzureCloud: azsettings.AzureChina}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.azureCloud"}, fieldErrorPaths(t, err))
+		assert.ErrorContains(t, err, "workload identity in the Azure cloud 'AzureChinaCloud' is not allowed in Grafana config")
+
+		settings.WorkloadIdentitySettings = &azsettings.WorkloadIdentitySettings{CrossCloudEnabled: true}
+		assert.NoError(t, credentials.Validate(settings))
+	})
+
+	t.Run("should return error for authority of other cloud", func(t *testing.T) {
+		credentials := &AzureClientCertificateCredentials{
+			AzureCloud:        azsettings.AzurePublic,
+			Authority:         "https://login.contoso.com/",
+			TenantId:          "TENANT-ID",
+			ClientId:          "CLIENT-ID",
+			ClientCertificate: "FAKE-CERTIFICATE",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.authority"}, fieldErrorPaths(t, err))
+		assert.ErrorContains(t, err, "the authority 'https://login.contoso.com/' belongs to the Azure cloud 'CustomCloud'")
+	})
+
+	t.Run("should accept authority of configured cloud", func(t *testing.T) {
+		credentials := &AzureClientSecretCredentials{
+			AzureCloud:   "CustomCloud",
+			Authority:    "https://login.contoso.com",
+			TenantId:     "TENANT-ID",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-SECRET",
+		}
+
+		err := credentials.Validate(settings)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error for invalid certificate format", func(t *testing.T) {
+		credentials := &AzureClientCertificateCredentials{
+			AzureCloud:        azsettings.AzurePublic,
+			TenantId:          "TENANT-ID",
+			ClientId:          "CLIENT-ID",
+			CertificateFormat: "invalid",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.certificateFormat"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if Entra password credentials disabled", func(t *testing.T) {
+		credentials := &AzureEntraPasswordCredentials{
+			UserId:   "user1@example.org",
+			ClientId: "CLIENT-ID",
+			Password: "FAKE-PASSWORD",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.authType"}, fieldErrorPaths(t, err))
+
+		err = credentials.Validate(&azsettings.AzureSettings{AzureEntraPasswordCredentialsEnabled: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error for API key credentials without header name and key", func(t *testing.T) {
+		credentials := &AzureApiKeyCredentials{}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.headerName", "secureJsonData.apiKey"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error for API key credentials with invalid header name", func(t *testing.T) {
+		credentials := &AzureApiKeyCredentials{
+			HeaderName: "x api key",
+			ApiKey:     "FAKE-API-KEY",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.headerName"}, fieldErrorPaths(t, err))
+		assert.ErrorContains(t, err, "invalid header name 'x api key'")
+	})
+
+	t.Run("should return error for storage shared key credentials with invalid account key", func(t *testing.T) {
+		credentials := &AzureStorageSharedKeyCredentials{
+			AccountName: "myaccount",
+			AccountKey:  "not base64",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"secureJsonData.accountKey"}, fieldErrorPaths(t, err))
+
+		err = (&AzureStorageSharedKeyCredentials{}).Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.accountName", "secureJsonData.accountKey"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error for storage SAS credentials without signature", func(t *testing.T) {
+		credentials := &AzureStorageSasCredentials{
+			SasToken: "sv=2022-11-02&sp=rl",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"secureJsonData.sasToken"}, fieldErrorPaths(t, err))
+
+		credentials.SasToken = "?sv=2022-11-02&sp=rl&sig=FAKE-SIGNATURE"
+		assert.NoError(t, credentials.Validate(settings))
+	})
+
+	t.Run("should return error if fallback credentials are API key credentials", func(t *testing.T) {
+		credentials := &AadCurrentUserCredentials{
+			ServiceCredentialsEnabled: true,
+			ServiceCredentials:        &AzureApiKeyCredentials{HeaderName: "x-api-key", ApiKey: "FAKE-API-KEY"},
+		}
+
+		err := credentials.Validate(&azsettings.AzureSettings{UserIdentityEnabled: true})
+		assert.Equal(t, []string{"azureCredentials.serviceCredentials.authType"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if fallback credentials are user credentials", func(t *testing.T) {
+		credentials := &AadCurrentUserCredentials{
+			ServiceCredentialsEnabled: true,
+			ServiceCredentials:        &AzureClientSecretOboCredentials{},
+		}
+
+		err := credentials.Validate(&azsettings.AzureSettings{UserIdentityEnabled: true})
+		assert.Equal(t, []string{"azureCredentials.serviceCredentials.authType"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if client assertion file not allowed", func(t *testing.T) {
+		credentials := &AzureClientAssertionCredentials{
+			AzureCloud:    azsettings.AzurePublic,
+			TenantId:      "TENANT-ID",
+			ClientId:      "CLIENT-ID",
+			AssertionFile: "/var/run/secrets/tokens/azure-identity-token",
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.authType", "azureCredentials.assertionFile"}, fieldErrorPaths(t, err))
+
+		err = credentials.Validate(&azsettings.AzureSettings{ClientAssertionCredentialsEnabled: true})
+		assert.Equal(t, []string{"azureCredentials.assertionFile"}, fieldErrorPaths(t, err))
+
+		err = credentials.Validate(&azsettings.AzureSettings{
+			ClientAssertionCredentialsEnabled: true,
+			ClientAssertionAllowedFiles:       []string{"/var/run/secrets/tokens/azure-identity-token"},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should accept client assertion callback", func(t *testing.T) {
+		credentials := &AzureClientAssertionCredentials{
+			AzureCloud: azsettings.AzurePublic,
+			TenantId:   "TENANT-ID",
+			ClientId:   "CLIENT-ID",
+			GetAssertion: func(ctx context.Context) (string, error) {
+				return "FAKE-ASSERTION", nil
+			},
+			AssertionKey: "FAKE-ASSERTION-KEY",
+		}
+
+		err := credentials.Validate(settings)
+		assert.NoError(t, err)
+
+		credentials.AssertionKey = ""
+		err = credentials.Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.assertionKey"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should accept chained credentials with some credentials not enabled", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Credentials: []AzureCredentials{
+				&AzureManagedIdentityCredentials{},
+				&AzureClientSecretCredentials{
+					AzureCloud:   azsettings.AzurePublic,
+					TenantId:     "TENANT-ID",
+					ClientId:     "CLIENT-ID",
+					ClientSecret: "FAKE-SECRET",
+				},
+			},
+		}
+
+		err := credentials.Validate(settings)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return errors of chained credentials", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Credentials: []AzureCredentials{
+				&AzureManagedIdentityCredentials{},
+				&AzureClientSecretCredentials{
+					AzureCloud:   azsettings.AzurePublic,
+					ClientId:     "CLIENT-ID",
+					ClientSecret: "FAKE-SECRET",
+				},
+				&AadCurrentUserCredentials{},
+			},
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{
+			"azureCredentials.credentials[1].tenantId",
+			"azureCredentials.credentials[2].authType",
+			"azureCredentials.credentials[0].authType",
+		}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return errors of secure fields of chained credentials with prefixed path", func(t *testing.T) {
+		credentials := &AzureChainedCredentials{
+			Credentials: []AzureCredentials{
+				&AzureManagedIdentityCredentials{},
+				&AzureClientSecretCredentials{
+					AzureCloud: azsettings.AzurePublic,
+					TenantId:   "TENANT-ID",
+					ClientId:   "CLIENT-ID",
+				},
+			},
+		}
+
+		err := credentials.Validate(settings)
+		assert.Equal(t, []string{
+			"secureJsonData.credentials.1.azureClientSecret",
+			"azureCredentials.credentials[0].authType",
+		}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if more than one managed identity selector set", func(t *testing.T) {
+		credentials := &AzureManagedIdentityCredentials{
+			ObjectId:   "OBJECT-ID",
+			ResourceId: "RESOURCE-ID",
+		}
+
+		err := credentials.Validate(&azsettings.AzureSettings{
+			ManagedIdentityEnabled:          true,
+			ManagedIdentityAllowedClientIds: []string{"OBJECT-ID", "RESOURCE-ID"},
+		})
+		assert.Equal(t, []string{"azureCredentials.resourceId"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if managed identity not allowed", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			ManagedIdentityEnabled:          true,
+			ManagedIdentityAllowedClientIds: []string{"ALLOWED-CLIENT-ID"},
+		}
+
+		err := (&AzureManagedIdentityCredentials{ClientId: "ALLOWED-CLIENT-ID"}).Validate(settings)
+		assert.NoError(t, err)
+
+		err = (&AzureManagedIdentityCredentials{ClientId: "OTHER-CLIENT-ID"}).Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.clientId"}, fieldErrorPaths(t, err))
+
+		err = (&AzureManagedIdentityCredentials{ObjectId: "OBJECT-ID"}).Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.objectId"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should return error if workload identity not allowed", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+				AllowedClientIds: []string{"ALLOWED-CLIENT-ID"},
+			},
+		}
+
+		err := (&AzureWorkloadIdentityCredentials{ClientId: "OTHER-CLIENT-ID"}).Validate(settings)
+		assert.Equal(t, []string{"azureCredentials.clientId"}, fieldErrorPaths(t, err))
+	})
+
+	t.Run("should expose field errors with errors.As", func(t *testing.T) {
+		credentials := &AzureWorkloadIdentityCredentials{}
+
+		err := credentials.Validate(settings)
+		var fieldErr *FieldError
+		require.True(t, errors.As(err, &fieldErr))
+		assert.Equal(t, "azureCredentials.authType", fieldErr.Path)
+	})
+
+	t.Run("should not validate custom credentials which are not validatable", func(t *testing.T) {
+		err := ValidateCredentials(settings, &customCredentials{})
+		assert.NoError(t, err)
+	})
+}
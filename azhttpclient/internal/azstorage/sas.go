@@ -0,0 +1,31 @@
+package azstorage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseSasToken returns the SAS token without the leading "?", an error is returned if the token
+// isn't a valid query string with a signature
+func ParseSasToken(sasToken string) (string, error) {
+	sasToken = strings.TrimPrefix(sasToken, "?")
+	query, err := url.ParseQuery(sasToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid SAS token: %w", err)
+	}
+	if !query.Has("sig") {
+		return "", fmt.Errorf("invalid SAS token: signature not found")
+	}
+	return sasToken, nil
+}
+
+// AppendSasToken appends the SAS token to the query of the URL, the token is appended as is
+// because the signature covers the encoded values
+func AppendSasToken(u *url.URL, sasToken string) {
+	if u.RawQuery == "" {
+		u.RawQuery = sasToken
+	} else {
+		u.RawQuery = u.RawQuery + "&" + sasToken
+	}
+}
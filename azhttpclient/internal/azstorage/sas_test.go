@@ -0,0 +1,48 @@
+package azstorage
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSasToken(t *testing.T) {
+	t.Run("should remove leading question mark", func(t *testing.T) {
+		sasToken, err := ParseSasToken("?sv=2022-11-02&sp=r&sig=FAKE%2BSIGNATURE%3D")
+		require.NoError(t, err)
+
+		assert.Equal(t, "sv=2022-11-02&sp=r&sig=FAKE%2BSIGNATURE%3D", sasToken)
+	})
+
+	t.Run("should return error if signature not set", func(t *testing.T) {
+		_, err := ParseSasToken("sv=2022-11-02&sp=r")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error for invalid query", func(t *testing.T) {
+		_, err := ParseSasToken("sv=2022-11-02&sig=%zz")
+		assert.Error(t, err)
+	})
+}
+
+func TestAppendSasToken(t *testing.T) {
+	t.Run("should append token to URL without query", func(t *testing.T) {
+		u, err := url.Parse("https://myaccount.blob.core.windows.net/mycontainer/data.csv")
+		require.NoError(t, err)
+
+		AppendSasToken(u, "sv=2022-11-02&sig=FAKE%2BSIGNATURE%3D")
+
+		assert.Equal(t, "https://myaccount.blob.core.windows.net/mycontainer/data.csv?sv=2022-11-02&sig=FAKE%2BSIGNATURE%3D", u.String())
+	})
+
+	t.Run("should append token to query without re-encoding", func(t *testing.T) {
+		u, err := url.Parse("https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=list")
+		require.NoError(t, err)
+
+		AppendSasToken(u, "sv=2022-11-02&sig=FAKE%2BSIGNATURE%3D")
+
+		assert.Equal(t, "restype=container&comp=list&sv=2022-11-02&sig=FAKE%2BSIGNATURE%3D", u.RawQuery)
+	})
+}
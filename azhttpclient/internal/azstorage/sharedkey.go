@@ -0,0 +1,164 @@
+package azstorage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignSharedKey signs the request with the key of the storage account as described in
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+//
+// The x-ms-date header is set to the given time if the request doesn't have it, the x-ms-version header
+// should be set by the caller as it defines the API version of the service.
+func SignSharedKey(req *http.Request, accountName string, accountKey []byte, now time.Time) error {
+	if req.Header.Get("x-ms-date") == "" {
+		req.Header.Set("x-ms-date", now.UTC().Format(http.TimeFormat))
+	}
+
+	stringToSign, err := StringToSign(req, accountName)
+	if err != nil {
+		return err
+	}
+
+	h := hmac.New(sha256.New, accountKey)
+	h.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", accountName, signature))
+	return nil
+}
+
+// StringToSign returns the string signed with the account key, the Table service uses a shorter string
+// than the Blob, Queue and File services
+func StringToSign(req *http.Request, accountName string) (string, error) {
+	canonicalizedResource, err := canonicalizedResource(req.URL, accountName, isTableService(req.URL))
+	if err != nil {
+		return "", err
+	}
+
+	if isTableService(req.URL) {
+		return strings.Join([]string{
+			req.Method,
+			req.Header.Get("Content-MD5"),
+			req.Header.Get("Content-Type"),
+			req.Header.Get("x-ms-date"),
+			canonicalizedResource,
+		}, "\n"), nil
+	}
+
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		// Date is empty as the x-ms-date header is always set
+		"",
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders(req.Header) + canonicalizedResource,
+	}, "\n"), nil
+}
+
+// canonicalizedHeaders returns the x-ms- headers sorted by name, each followed by a new line
+func canonicalizedHeaders(header http.Header) string {
+	values := map[string][]string{}
+	for key, headerValues := range header {
+		name := strings.ToLower(strings.TrimSpace(key))
+		if !strings.HasPrefix(name, "x-ms-") {
+			continue
+		}
+		for _, value := range headerValues {
+			values[name] = append(values[name], strings.TrimSpace(value))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result strings.Builder
+	for _, name := range names {
+		result.WriteString(name)
+		result.WriteString(":")
+		result.WriteString(strings.Join(values[name], ","))
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// canonicalizedResource returns the account and the path of the resource followed by the query parameters
+// sorted by name, the Table service includes only the comp parameter
+func canonicalizedResource(u *url.URL, accountName string, table bool) (string, error) {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	resource := "/" + accountName + path
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid query of the request: %w", err)
+	}
+
+	if table {
+		if comp, ok := query["comp"]; ok && len(comp) > 0 {
+			resource += "?comp=" + comp[0]
+		}
+		return resource, nil
+	}
+
+	params := map[string][]string{}
+	for name, values := range query {
+		name = strings.ToLower(name)
+		params[name] = append(params[name], values...)
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values := params[name]
+		sort.Strings(values)
+		resource += "\n" + name + ":" + strings.Join(values, ",")
+	}
+	return resource, nil
+}
+
+// azuriteTablePort is the default port of the Table service of the Azurite emulator, which uses path-style
+// URLs with the account name in the path, e.g. http://127.0.0.1:10002/devstoreaccount1
+const azuriteTablePort = "10002"
+
+// isTableService returns true for requests to the Table service endpoint of a storage account,
+// e.g. https://account.table.core.windows.net, or to the default Table endpoint of Azurite.
+// Requests to other hosts, e.g. custom domains (which Azure Storage supports only for the Blob service)
+// or emulators on other ports, are signed as requests to the Blob, Queue or File service.
+func isTableService(u *url.URL) bool {
+	labels := strings.Split(u.Hostname(), ".")
+	if len(labels) > 2 && labels[1] == "table" {
+		return true
+	}
+	return u.Port() == azuriteTablePort
+}
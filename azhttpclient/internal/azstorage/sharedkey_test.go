@@ -0,0 +1,153 @@
+package azstorage
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Fake base64 encoded account key. The strings to sign are written after the Shared Key format described in
+// the Storage REST API documentation and the signatures are their HMAC-SHA256 computed independently of this
+// package, e.g. with `printf '%s' "$STRING_TO_SIGN" | openssl dgst -sha256 -mac HMAC -macopt key:"$KEY" -binary | base64`
+// where KEY is the decoded account key.
+const testAccountKeyBase64 = "RkFLRS1TVE9SQUdFLUFDQ09VTlQtS0VZLUZPUi1TSEFSRUQtS0VZLVRFU1RTLTAxMjM0NTY3ODlBQkNERUZHSA=="
+
+var testAccountKey, _ = base64.StdEncoding.DecodeString(testAccountKeyBase64)
+
+const testDate = "Fri, 26 Jun 2015 23:39:12 GMT"
+
+func TestSignSharedKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		headers       map[string]string
+		stringToSign  string
+		authorization string
+	}{
+		{
+			name:          "list containers",
+			method:        "GET",
+			url:           "https://myaccount.blob.core.windows.net/?comp=list",
+			headers:       map[string]string{"x-ms-version": "2021-08-06"},
+			stringToSign:  "GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2021-08-06\n/myaccount/\ncomp:list",
+			authorization: "SharedKey myaccount:TzflbuAXfH4AdmNVqJqoU7duD7WMyoupuDzeNHa+H44=",
+		},
+		{
+			name:   "put blob with metadata",
+			method: "PUT",
+			url:    "https://myaccount.blob.core.windows.net/mycontainer/my%20blob.txt",
+			body:   "hello world",
+			headers: map[string]string{
+				"x-ms-version":       "2021-08-06",
+				"Content-Type":       "text/plain; charset=UTF-8",
+				"x-ms-blob-type":     "BlockBlob",
+				"x-ms-meta-Category": "Logs",
+			},
+			stringToSign:  "PUT\n\n\n11\n\ntext/plain; charset=UTF-8\n\n\n\n\n\n\nx-ms-blob-type:BlockBlob\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-meta-category:Logs\nx-ms-version:2021-08-06\n/myaccount/mycontainer/my%20blob.txt",
+			authorization: "SharedKey myaccount:mL0ZVt1EsLIKfWgYGbAYSWctx9Cq5whg+Ep7EbccciM=",
+		},
+		{
+			name:          "list blobs with repeated query parameters",
+			method:        "GET",
+			url:           "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=list&include=snapshots&include=metadata&prefix=logs%2F2024",
+			headers:       map[string]string{"x-ms-version": "2021-08-06"},
+			stringToSign:  "GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2021-08-06\n/myaccount/mycontainer\ncomp:list\ninclude:metadata,snapshots\nprefix:logs/2024\nrestype:container",
+			authorization: "SharedKey myaccount:ac2Z6vSSBND+1TNYa5bBLWdXCSsRDj/g2oqyMK8gOTU=",
+		},
+		{
+			name:   "get blob range with condition",
+			method: "GET",
+			url:    "https://myaccount.blob.core.windows.net/mycontainer/data.csv",
+			headers: map[string]string{
+				"x-ms-version": "2021-08-06",
+				"Range":        "bytes=0-1023",
+				"If-Match":     `"0x8D4BCC2E4835CD0"`,
+			},
+			stringToSign:  "GET\n\n\n\n\n\n\n\n\"0x8D4BCC2E4835CD0\"\n\n\nbytes=0-1023\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2021-08-06\n/myaccount/mycontainer/data.csv",
+			authorization: "SharedKey myaccount:NTyxySh+UBE2/J8QuTdtcJdBIOWoURD/WFqLVOoF5Kg=",
+		},
+		{
+			name:   "peek queue messages",
+			method: "GET",
+			url:    "https://myaccount.queue.core.windows.net/myqueue/messages?numofmessages=5&visibilitytimeout=30",
+			headers: map[string]string{
+				"x-ms-version":           "2021-08-06",
+				"x-ms-client-request-id": "00000000-0000-0000-0000-000000000001",
+			},
+			stringToSign:  "GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-client-request-id:00000000-0000-0000-0000-000000000001\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2021-08-06\n/myaccount/myqueue/messages\nnumofmessages:5\nvisibilitytimeout:30",
+			authorization: "SharedKey myaccount:g7MFcG2T4xzPSEHniVm2FRvMtCNSiDTrstCxVHGVF0o=",
+		},
+		{
+			name:          "query table entities",
+			method:        "GET",
+			url:           "https://myaccount.table.core.windows.net/mytable()?$filter=PartitionKey%20eq%20'logs'",
+			headers:       map[string]string{"x-ms-version": "2019-02-02"},
+			stringToSign:  "GET\n\n\nFri, 26 Jun 2015 23:39:12 GMT\n/myaccount/mytable()",
+			authorization: "SharedKey myaccount:MK/S+luJk9KwbAYlc2YzEptUfuNctioV0soqP+I8FwQ=",
+		},
+		{
+			name:          "get table service properties",
+			method:        "GET",
+			url:           "https://myaccount.table.core.windows.net/?restype=service&comp=properties",
+			headers:       map[string]string{"x-ms-version": "2019-02-02"},
+			stringToSign:  "GET\n\n\nFri, 26 Jun 2015 23:39:12 GMT\n/myaccount/?comp=properties",
+			authorization: "SharedKey myaccount:M1UwHSrdBV/dHYtQfE4qwMNN+D12IluyikyN9ykrSIc=",
+		},
+		{
+			name:          "query table entities on Azurite",
+			method:        "GET",
+			url:           "http://127.0.0.1:10002/myaccount/mytable()?$filter=PartitionKey%20eq%20'logs'",
+			headers:       map[string]string{"x-ms-version": "2019-02-02"},
+			stringToSign:  "GET\n\n\nFri, 26 Jun 2015 23:39:12 GMT\n/myaccount/myaccount/mytable()",
+			authorization: "SharedKey myaccount:g2/MRqN3wSksMlptWwlXdXw8BzoU4z4amxrtMmlwM5I=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			req.Header.Set("x-ms-date", testDate)
+
+			stringToSign, err := StringToSign(req, "myaccount")
+			require.NoError(t, err)
+			assert.Equal(t, tt.stringToSign, stringToSign)
+
+			err = SignSharedKey(req, "myaccount", testAccountKey, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, tt.authorization, req.Header.Get("Authorization"))
+		})
+	}
+
+	t.Run("should set date if not set", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net/?comp=list", nil)
+		require.NoError(t, err)
+		req.Header.Set("x-ms-version", "2021-08-06")
+
+		now, err := time.Parse(http.TimeFormat, testDate)
+		require.NoError(t, err)
+
+		err = SignSharedKey(req, "myaccount", testAccountKey, now)
+		require.NoError(t, err)
+		assert.Equal(t, testDate, req.Header.Get("x-ms-date"))
+		assert.Equal(t, "SharedKey myaccount:TzflbuAXfH4AdmNVqJqoU7duD7WMyoupuDzeNHa+H44=", req.Header.Get("Authorization"))
+	})
+
+	t.Run("should return error for invalid query", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net/?comp=%zz", nil)
+		require.NoError(t, err)
+
+		err = SignSharedKey(req, "myaccount", testAccountKey, time.Now())
+		assert.Error(t, err)
+	})
+}
@@ -1,12 +1,15 @@
 package azhttpclient
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azhttpclient/internal/azendpoint"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azhttpclient/internal/azstorage"
 	"github.com/grafana/grafana-azure-sdk-go/v2/aztokenprovider"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 )
@@ -19,6 +22,33 @@ func AzureMiddleware(authOpts *AuthOptions, credentials azcredentials.AzureCrede
 		var tokenProvider aztokenprovider.AzureTokenProvider = nil
 		var sessionProvider *userSessionProvider = nil
 
+		// Keys are sent with the requests instead of an access token
+		switch c := credentials.(type) {
+		case *azcredentials.AzureApiKeyCredentials:
+			if c.HeaderName == "" || c.ApiKey == "" {
+				err = errors.New("API key header name and key must be set")
+				return errorResponse(err)
+			}
+			return applyApiKeyAuth(c, authOpts.endpoints, next)
+		case *azcredentials.AzureStorageSharedKeyCredentials:
+			if c.AccountName == "" {
+				err = errors.New("storage account name must be set")
+				return errorResponse(err)
+			}
+			accountKey, err := base64.StdEncoding.DecodeString(c.AccountKey)
+			if err != nil || len(accountKey) == 0 {
+				err = errors.New("storage account key must be base64 encoded")
+				return errorResponse(err)
+			}
+			return applyStorageSharedKeyAuth(c.AccountName, accountKey, authOpts.endpoints, next)
+		case *azcredentials.AzureStorageSasCredentials:
+			sasToken, err := azstorage.ParseSasToken(c.SasToken)
+			if err != nil {
+				return errorResponse(err)
+			}
+			return applyStorageSasAuth(sasToken, authOpts.endpoints, next)
+		}
+
 		if tokenProviderFactory, ok := authOpts.customProviders[credentials.AzureAuthType()]; ok && tokenProviderFactory != nil {
 			tokenProvider, err = tokenProviderFactory(authOpts.settings, credentials)
 		} else {
@@ -52,14 +82,8 @@ func applyAzureAuth(tokenProvider aztokenprovider.AzureTokenProvider, sessionPro
 		}
 		reqContext := req.Context()
 
-		if endpoints != nil {
-			endpoint := azendpoint.Endpoint(*req.URL)
-			if endpoint == nil {
-				return nil, fmt.Errorf("request to invalid endpoint '%s' is not allowed by the datasource", req.URL.String())
-			}
-			if !endpoints.IsAllowed(endpoint) {
-				return nil, fmt.Errorf("request to endpoint '%s' is not allowed by the datasource", endpoint.String())
-			}
+		if err := checkEndpointAllowed(endpoints, req); err != nil {
+			return nil, err
 		}
 
 		token, err := tokenProvider.GetAccessToken(reqContext, scopes)
@@ -86,6 +110,77 @@ func applyAzureAuth(tokenProvider aztokenprovider.AzureTokenProvider, sessionPro
 	})
 }
 
+func applyApiKeyAuth(credentials *azcredentials.AzureApiKeyCredentials, endpoints *azendpoint.EndpointAllowlist,
+	next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req == nil {
+			return nil, fmt.Errorf("request is nil")
+		}
+
+		// The API key must not be sent to endpoints not allowed by the datasource
+		if err := checkEndpointAllowed(endpoints, req); err != nil {
+			return nil, err
+		}
+
+		req.Header.Set(credentials.HeaderName, credentials.ApiKey)
+
+		return next.RoundTrip(req)
+	})
+}
+
+func applyStorageSharedKeyAuth(accountName string, accountKey []byte, endpoints *azendpoint.EndpointAllowlist,
+	next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req == nil {
+			return nil, fmt.Errorf("request is nil")
+		}
+
+		if err := checkEndpointAllowed(endpoints, req); err != nil {
+			return nil, err
+		}
+
+		// The original request isn't modified, so that the date is set again on retry
+		req = req.Clone(req.Context())
+		if err := azstorage.SignSharedKey(req, accountName, accountKey, time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to sign request with storage account key: %w", err)
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+func applyStorageSasAuth(sasToken string, endpoints *azendpoint.EndpointAllowlist, next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req == nil {
+			return nil, fmt.Errorf("request is nil")
+		}
+
+		if err := checkEndpointAllowed(endpoints, req); err != nil {
+			return nil, err
+		}
+
+		// The original request isn't modified, so that the token isn't appended again on retry
+		req = req.Clone(req.Context())
+		azstorage.AppendSasToken(req.URL, sasToken)
+
+		return next.RoundTrip(req)
+	})
+}
+
+func checkEndpointAllowed(endpoints *azendpoint.EndpointAllowlist, req *http.Request) error {
+	if endpoints == nil {
+		return nil
+	}
+	endpoint := azendpoint.Endpoint(*req.URL)
+	if endpoint == nil {
+		return fmt.Errorf("request to invalid endpoint '%s' is not allowed by the datasource", req.URL.String())
+	}
+	if !endpoints.IsAllowed(endpoint) {
+		return fmt.Errorf("request to endpoint '%s' is not allowed by the datasource", endpoint.String())
+	}
+	return nil
+}
+
 func errorResponse(err error) http.RoundTripper {
 	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("invalid Azure configuration: %s", err)
@@ -44,6 +44,37 @@ func TestAzureMiddleware(t *testing.T) {
 		assert.True(t, testTokenProvider.Called)
 	})
 
+	t.Run("should use custom provider for custom credentials parsed from datasource data", func(t *testing.T) {
+		err := azcredentials.RegisterCredentialsParser(azureAuthCustom, func(_ map[string]interface{}, _ map[string]string) (azcredentials.AzureCredentials, error) {
+			return &customCredentials{}, nil
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { azcredentials.UnregisterCredentialsParser(azureAuthCustom) })
+
+		authOpts := NewAuthOptions(azureSettings)
+		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
+		testTokenProvider := &customTokenProvider{}
+		authOpts.AddTokenProvider(azureAuthCustom, func(_ *azsettings.AzureSettings, _ azcredentials.AzureCredentials) (aztokenprovider.AzureTokenProvider, error) {
+			return testTokenProvider, nil
+		})
+
+		credentials, err := azcredentials.FromDatasourceData(map[string]interface{}{
+			"azureCredentials": map[string]interface{}{
+				"authType": azureAuthCustom,
+			},
+		}, map[string]string{})
+		require.NoError(t, err)
+		middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, next)
+
+		req, err := http.NewRequest("GET", "https://testendpoint.microsoft.com", nil)
+		require.NoError(t, err)
+
+		resp, err := middleware.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, testTokenProvider.Called)
+	})
+
 	t.Run("should return error if custom provider not registered for given custom credentials", func(t *testing.T) {
 		authOpts := NewAuthOptions(azureSettings)
 		authOpts.Scopes([]string{"https://datasource.example.org/.default"})
@@ -139,6 +170,182 @@ func TestAzureMiddleware(t *testing.T) {
 		})
 	})
 
+	t.Run("given API key credentials", func(t *testing.T) {
+		credentials := &azcredentials.AzureApiKeyCredentials{
+			HeaderName: "x-api-key",
+			ApiKey:     "FAKE-API-KEY",
+		}
+
+		t.Run("should set API key header instead of access token", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://api.applicationinsights.io", nil)
+			require.NoError(t, err)
+
+			resp, err := middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, "FAKE-API-KEY", capture.lastReq.Header.Get("x-api-key"))
+			assert.Empty(t, capture.lastReq.Header.Get("Authorization"))
+		})
+
+		t.Run("should not send API key to endpoint not in the allowlist", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			err := authOpts.AllowedEndpoints([]string{"https://api.applicationinsights.io"})
+			require.NoError(t, err)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://another.com", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.Error(t, err)
+			assert.Nil(t, capture.lastReq)
+		})
+
+		t.Run("should send API key to endpoint in the allowlist", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			err := authOpts.AllowedEndpoints([]string{"https://api.applicationinsights.io"})
+			require.NoError(t, err)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, "FAKE-API-KEY", capture.lastReq.Header.Get("x-api-key"))
+		})
+
+		t.Run("should return error if API key not set", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			middleware := AzureMiddleware(authOpts, &azcredentials.AzureApiKeyCredentials{HeaderName: "api-key"}).CreateMiddleware(clientOpts, next)
+
+			req, err := http.NewRequest("GET", "https://api.applicationinsights.io", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.EqualError(t, err, "invalid Azure configuration: API key header name and key must be set")
+		})
+	})
+
+	t.Run("given storage shared key credentials", func(t *testing.T) {
+		credentials := &azcredentials.AzureStorageSharedKeyCredentials{
+			AccountName: "myaccount",
+			AccountKey:  "RkFLRS1TVE9SQUdFLUFDQ09VTlQtS0VZLUZPUi1UWVBFLVRFU1RT",
+		}
+
+		t.Run("should sign request with account key", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net/?comp=list", nil)
+			require.NoError(t, err)
+			req.Header.Set("x-ms-version", "2021-08-06")
+			req.Header.Set("x-ms-date", "Fri, 26 Jun 2015 23:39:12 GMT")
+
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, "SharedKey myaccount:zMt7rEXT4qlXodeHLpabgNVdnBq/yFWiYIvSJ0nnlf0=", capture.lastReq.Header.Get("Authorization"))
+			assert.Empty(t, req.Header.Get("Authorization"))
+		})
+
+		t.Run("should set date of request", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net/?comp=list", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.NotEmpty(t, capture.lastReq.Header.Get("x-ms-date"))
+		})
+
+		t.Run("should not sign request to endpoint not in the allowlist", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			err := authOpts.AllowedEndpoints([]string{"https://*.blob.core.windows.net"})
+			require.NoError(t, err)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://another.com", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.Error(t, err)
+			assert.Nil(t, capture.lastReq)
+		})
+
+		t.Run("should return error if account key invalid", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			middleware := AzureMiddleware(authOpts, &azcredentials.AzureStorageSharedKeyCredentials{
+				AccountName: "myaccount",
+				AccountKey:  "not base64",
+			}).CreateMiddleware(clientOpts, next)
+
+			req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.EqualError(t, err, "invalid Azure configuration: storage account key must be base64 encoded")
+		})
+	})
+
+	t.Run("given storage SAS credentials", func(t *testing.T) {
+		credentials := &azcredentials.AzureStorageSasCredentials{
+			SasToken: "?sv=2022-11-02&sp=rl&sig=FAKE%2BSIGNATURE%3D",
+		}
+
+		t.Run("should append SAS token to query of request", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=list", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, "restype=container&comp=list&sv=2022-11-02&sp=rl&sig=FAKE%2BSIGNATURE%3D", capture.lastReq.URL.RawQuery)
+			assert.Equal(t, "restype=container&comp=list", req.URL.RawQuery)
+		})
+
+		t.Run("should not send SAS token to endpoint not in the allowlist", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			err := authOpts.AllowedEndpoints([]string{"https://*.blob.core.windows.net"})
+			require.NoError(t, err)
+			capture := &testRoundTripper{}
+			middleware := AzureMiddleware(authOpts, credentials).CreateMiddleware(clientOpts, capture)
+
+			req, err := http.NewRequest("GET", "https://another.com", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.Error(t, err)
+			assert.Nil(t, capture.lastReq)
+		})
+
+		t.Run("should return error if SAS token invalid", func(t *testing.T) {
+			authOpts := NewAuthOptions(azureSettings)
+			middleware := AzureMiddleware(authOpts, &azcredentials.AzureStorageSasCredentials{
+				SasToken: "sv=2022-11-02",
+			}).CreateMiddleware(clientOpts, next)
+
+			req, err := http.NewRequest("GET", "https://myaccount.blob.core.windows.net", nil)
+			require.NoError(t, err)
+
+			_, err = middleware.RoundTrip(req)
+			assert.EqualError(t, err, "invalid Azure configuration: invalid SAS token: signature not found")
+		})
+	})
+
 	t.Run("given rate-limit session enabled", func(t *testing.T) {
 		newMiddleware := func(capture *testRoundTripper) http.RoundTripper {
 			authOpts := NewAuthOptions(azureSettings)
@@ -3,6 +3,8 @@ package azsettings
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 type AzureCloudInfo struct {
@@ -71,6 +73,37 @@ func (settings *AzureSettings) GetCloud(cloudName string) (*AzureCloudSettings,
 	return nil, fmt.Errorf("the Azure cloud '%s' is not supported", cloudName)
 }
 
+// GetCloudByAuthority returns the predefined or custom cloud with the given Entra ID authority host, predefined
+// clouds take precedence over custom clouds with the same authority
+func (settings *AzureSettings) GetCloudByAuthority(authority string) (*AzureCloudSettings, error) {
+	clouds := settings.getClouds()
+
+	for _, cloud := range clouds {
+		if IsSameAuthority(cloud.AadAuthority, authority) {
+			return cloud, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the authority '%s' doesn't belong to any known Azure cloud", authority)
+}
+
+// IsSameAuthority returns true if both authority URLs refer to the same host, regardless of the case,
+// the trailing slash and the path
+func IsSameAuthority(authority string, other string) bool {
+	host := getAuthorityHost(authority)
+	return host != "" && host == getAuthorityHost(other)
+}
+
+func getAuthorityHost(authority string) string {
+	authority = strings.TrimSpace(authority)
+	if u, err := url.Parse(authority); err == nil && u.Host != "" {
+		return strings.ToLower(u.Host)
+	}
+	// Authority without a scheme
+	host, _, _ := strings.Cut(authority, "/")
+	return strings.ToLower(host)
+}
+
 // Returns all clouds configured on the instance, including custom clouds if any
 func (settings *AzureSettings) Clouds() []AzureCloudInfo {
 	clouds := settings.getClouds()
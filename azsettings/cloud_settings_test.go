@@ -98,6 +98,45 @@ func TestGetCloud(t *testing.T) {
 	})
 }
 
+func TestGetCloudByAuthority(t *testing.T) {
+	settings := &AzureSettings{
+		CustomCloudList: testCustomClouds,
+	}
+
+	t.Run("should return predefined cloud of authority", func(t *testing.T) {
+		cloud, err := settings.GetCloudByAuthority("https://login.chinacloudapi.cn/")
+		require.NoError(t, err)
+
+		assert.Equal(t, AzureChina, cloud.Name)
+	})
+
+	t.Run("should return custom cloud of authority", func(t *testing.T) {
+		cloud, err := settings.GetCloudByAuthority("https://login.cloud2.contoso.com/")
+		require.NoError(t, err)
+
+		assert.Equal(t, "CustomCloud2", cloud.Name)
+	})
+
+	t.Run("should ignore case, trailing slash and path of authority", func(t *testing.T) {
+		for _, authority := range []string{"https://LOGIN.microsoftonline.us", "https://login.microsoftonline.us/TENANT-ID/", "login.microsoftonline.us"} {
+			cloud, err := settings.GetCloudByAuthority(authority)
+			require.NoError(t, err, authority)
+
+			assert.Equal(t, AzureUSGovernment, cloud.Name, authority)
+		}
+	})
+
+	t.Run("should return error if authority of unknown cloud", func(t *testing.T) {
+		_, err := settings.GetCloudByAuthority("https://login.fabrikam.com/")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error if authority not set", func(t *testing.T) {
+		_, err := settings.GetCloudByAuthority("")
+		assert.Error(t, err)
+	})
+}
+
 func TestSetCustomClouds(t *testing.T) {
 	settings := &AzureSettings{}
 
@@ -1,7 +1,11 @@
 package azsettings
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings/internal/envutil"
 )
@@ -15,11 +19,17 @@ const (
 	ManagedIdentityEnabled  = "GFAZPL_MANAGED_IDENTITY_ENABLED"
 	ManagedIdentityClientID = "GFAZPL_MANAGED_IDENTITY_CLIENT_ID"
 
+	ManagedIdentityAllowedClientIDs = "GFAZPL_MANAGED_IDENTITY_ALLOWED_CLIENT_IDS"
+
 	WorkloadIdentityEnabled   = "GFAZPL_WORKLOAD_IDENTITY_ENABLED"
 	WorkloadIdentityTenantID  = "GFAZPL_WORKLOAD_IDENTITY_TENANT_ID"
 	WorkloadIdentityClientID  = "GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID"
 	WorkloadIdentityTokenFile = "GFAZPL_WORKLOAD_IDENTITY_TOKEN_FILE"
 
+	WorkloadIdentityAllowedClientIDs = "GFAZPL_WORKLOAD_IDENTITY_ALLOWED_CLIENT_IDS"
+
+	WorkloadIdentityCrossCloudEnabled = "GFAZPL_WORKLOAD_IDENTITY_CROSS_CLOUD_ENABLED"
+
 	UserIdentityEnabled                     = "GFAZPL_USER_IDENTITY_ENABLED"
 	UserIdentityTokenURL                    = "GFAZPL_USER_IDENTITY_TOKEN_URL"
 	UserIdentityClientAuthentication        = "GFAZPL_USER_IDENTITY_CLIENT_AUTHENTICATION"
@@ -32,6 +42,13 @@ const (
 
 	AzureEntraPasswordCredentialsEnabled = "GFAZPL_AZURE_ENTRA_PASSWORD_CREDENTIALS_ENABLED"
 
+	ClientAssertionCredentialsEnabled = "GFAZPL_CLIENT_ASSERTION_CREDENTIALS_ENABLED"
+	ClientAssertionAllowedFiles       = "GFAZPL_CLIENT_ASSERTION_ALLOWED_FILES"
+
+	CertificateExpiryWarningWindow = "GFAZPL_CERTIFICATE_EXPIRY_WARNING_WINDOW"
+
+	ForwardSettingsPlugins = "GFAZPL_FORWARD_SETTINGS_PLUGINS"
+
 	// Pre Grafana 9.x variables
 	fallbackAzureCloud              = "AZURE_CLOUD"
 	fallbackManagedIdentityEnabled  = "AZURE_MANAGED_IDENTITY_ENABLED"
@@ -65,6 +82,7 @@ func ReadFromEnv() (*AzureSettings, error) {
 	} else if msiEnabled {
 		azureSettings.ManagedIdentityEnabled = true
 		azureSettings.ManagedIdentityClientId = envutil.GetOrFallback(ManagedIdentityClientID, fallbackManagedIdentityClientId, "")
+		azureSettings.ManagedIdentityAllowedClientIds = parseList(envutil.GetOrDefault(ManagedIdentityAllowedClientIDs, ""))
 	}
 
 	// Workload Identity authentication
@@ -78,6 +96,13 @@ func ReadFromEnv() (*AzureSettings, error) {
 		wiSettings.TenantId = envutil.GetOrDefault(WorkloadIdentityTenantID, "")
 		wiSettings.ClientId = envutil.GetOrDefault(WorkloadIdentityClientID, "")
 		wiSettings.TokenFile = envutil.GetOrDefault(WorkloadIdentityTokenFile, "")
+		wiSettings.AllowedClientIds = parseList(envutil.GetOrDefault(WorkloadIdentityAllowedClientIDs, ""))
+		if crossCloudEnabled, err := envutil.GetBoolOrDefault(WorkloadIdentityCrossCloudEnabled, false); err != nil {
+			err = fmt.Errorf("invalid Azure configuration: %w", err)
+			return nil, err
+		} else {
+			wiSettings.CrossCloudEnabled = crossCloudEnabled
+		}
 		azureSettings.WorkloadIdentitySettings = wiSettings
 	}
 
@@ -136,74 +161,165 @@ func ReadFromEnv() (*AzureSettings, error) {
 		azureSettings.AzureEntraPasswordCredentialsEnabled = AzureEntraPasswordCredentialsEnabled
 	}
 
+	// Client Assertion Credentials auth
+	if clientAssertionEnabled, err := envutil.GetBoolOrDefault(ClientAssertionCredentialsEnabled, false); err != nil {
+		err = fmt.Errorf("invalid Azure configuration: %w", err)
+		return nil, err
+	} else if clientAssertionEnabled {
+		azureSettings.ClientAssertionCredentialsEnabled = true
+		azureSettings.ClientAssertionAllowedFiles = parseList(envutil.GetOrDefault(ClientAssertionAllowedFiles, ""))
+	}
+
+	// Client certificate expiry warnings
+	if v := envutil.GetOrDefault(CertificateExpiryWarningWindow, ""); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil {
+			err = fmt.Errorf("invalid Azure configuration: invalid certificate expiry warning window: %w", err)
+			return nil, err
+		}
+		azureSettings.CertificateExpiryWarningWindow = window
+	}
+
+	azureSettings.ForwardSettingsPlugins = parseList(envutil.GetOrDefault(ForwardSettingsPlugins, ""))
+
 	return azureSettings, nil
 }
 
+// WriteToEnvStr returns the environment variables of the settings in the form "KEY=value", the settings
+// read back by ReadFromEnv are equal to the written ones, except that:
+//   - an empty Cloud is read back as AzurePublic;
+//   - CustomCloudList is written as JSON, so CustomCloudListJSON is set when read back;
+//   - user identity enabled without the TokenUrl or the ClientId of the token endpoint cannot be read back,
+//     ReadFromEnv returns an error for such settings.
 func WriteToEnvStr(azureSettings *AzureSettings) []string {
 	var envs []string
+	for _, v := range getSettingsVars(azureSettings) {
+		envs = append(envs, fmt.Sprintf("%s=%s", v.key, v.value))
+	}
+	return envs
+}
+
+type settingsVar struct {
+	key   string
+	value string
+}
+
+// getSettingsVars returns the variables of all the fields of the settings, variables of the disabled
+// authentication methods and of the fields with default values are omitted
+func getSettingsVars(azureSettings *AzureSettings) []settingsVar {
+	var vars []settingsVar
+	add := func(key string, value string) {
+		vars = append(vars, settingsVar{key: key, value: value})
+	}
 
 	if azureSettings != nil {
 		if azureSettings.Cloud != "" {
-			envs = append(envs, fmt.Sprintf("%s=%s", AzureCloud, azureSettings.Cloud))
+			add(AzureCloud, azureSettings.Cloud)
+		}
+
+		if customCloudsJSON := getCustomCloudsJSON(azureSettings); customCloudsJSON != "" {
+			add(AzureCustomCloudsConfig, customCloudsJSON)
 		}
 
 		if azureSettings.AzureAuthEnabled {
-			envs = append(envs, fmt.Sprintf("%s=true", AzureAuthEnabled))
+			add(AzureAuthEnabled, "true")
 		}
 
 		if azureSettings.ManagedIdentityEnabled {
-			envs = append(envs, fmt.Sprintf("%s=true", ManagedIdentityEnabled))
+			add(ManagedIdentityEnabled, "true")
 
 			if azureSettings.ManagedIdentityClientId != "" {
-				envs = append(envs, fmt.Sprintf("%s=%s", ManagedIdentityClientID, azureSettings.ManagedIdentityClientId))
+				add(ManagedIdentityClientID, azureSettings.ManagedIdentityClientId)
+			}
+			if len(azureSettings.ManagedIdentityAllowedClientIds) > 0 {
+				add(ManagedIdentityAllowedClientIDs, strings.Join(azureSettings.ManagedIdentityAllowedClientIds, ","))
 			}
 		}
 
 		if azureSettings.WorkloadIdentityEnabled {
-			envs = append(envs, fmt.Sprintf("%s=true", WorkloadIdentityEnabled))
+			add(WorkloadIdentityEnabled, "true")
 
 			if wiSettings := azureSettings.WorkloadIdentitySettings; wiSettings != nil {
 				if wiSettings.TenantId != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", WorkloadIdentityTenantID, wiSettings.TenantId))
+					add(WorkloadIdentityTenantID, wiSettings.TenantId)
 				}
 				if wiSettings.ClientId != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", WorkloadIdentityClientID, wiSettings.ClientId))
+					add(WorkloadIdentityClientID, wiSettings.ClientId)
 				}
 				if wiSettings.TokenFile != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", WorkloadIdentityTokenFile, wiSettings.TokenFile))
+					add(WorkloadIdentityTokenFile, wiSettings.TokenFile)
+				}
+				if len(wiSettings.AllowedClientIds) > 0 {
+					add(WorkloadIdentityAllowedClientIDs, strings.Join(wiSettings.AllowedClientIds, ","))
+				}
+				if wiSettings.CrossCloudEnabled {
+					add(WorkloadIdentityCrossCloudEnabled, "true")
 				}
 			}
 		}
 
 		if azureSettings.UserIdentityEnabled {
-			envs = append(envs, fmt.Sprintf("%s=true", UserIdentityEnabled))
-			envs = append(envs, fmt.Sprintf("%s=%t", UserIdentityFallbackCredentialsEnabled, azureSettings.UserIdentityFallbackCredentialsEnabled))
+			add(UserIdentityEnabled, "true")
+			add(UserIdentityFallbackCredentialsEnabled, strconv.FormatBool(azureSettings.UserIdentityFallbackCredentialsEnabled))
 
 			if tokenEndpoint := azureSettings.UserIdentityTokenEndpoint; tokenEndpoint != nil {
 				if tokenEndpoint.TokenUrl != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityTokenURL, tokenEndpoint.TokenUrl))
+					add(UserIdentityTokenURL, tokenEndpoint.TokenUrl)
 				}
 				if tokenEndpoint.ClientAuthentication != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityClientAuthentication, tokenEndpoint.ClientAuthentication))
+					add(UserIdentityClientAuthentication, tokenEndpoint.ClientAuthentication)
 				}
 				if tokenEndpoint.ClientId != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityClientID, tokenEndpoint.ClientId))
+					add(UserIdentityClientID, tokenEndpoint.ClientId)
 				}
 				if tokenEndpoint.ClientSecret != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityClientSecret, tokenEndpoint.ClientSecret))
+					add(UserIdentityClientSecret, tokenEndpoint.ClientSecret)
 				}
 				if tokenEndpoint.ManagedIdentityClientId != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityManagedIdentityClientID, tokenEndpoint.ManagedIdentityClientId))
+					add(UserIdentityManagedIdentityClientID, tokenEndpoint.ManagedIdentityClientId)
 				}
 				if tokenEndpoint.FederatedCredentialAudience != "" {
-					envs = append(envs, fmt.Sprintf("%s=%s", UserIdentityFederatedCredentialAudience, tokenEndpoint.FederatedCredentialAudience))
+					add(UserIdentityFederatedCredentialAudience, tokenEndpoint.FederatedCredentialAudience)
 				}
 				if tokenEndpoint.UsernameAssertion {
-					envs = append(envs, fmt.Sprintf("%s=username", UserIdentityAssertion))
+					add(UserIdentityAssertion, "username")
 				}
 			}
 		}
+
+		if azureSettings.AzureEntraPasswordCredentialsEnabled {
+			add(AzureEntraPasswordCredentialsEnabled, "true")
+		}
+
+		if azureSettings.ClientAssertionCredentialsEnabled {
+			add(ClientAssertionCredentialsEnabled, "true")
+
+			if len(azureSettings.ClientAssertionAllowedFiles) > 0 {
+				add(ClientAssertionAllowedFiles, strings.Join(azureSettings.ClientAssertionAllowedFiles, ","))
+			}
+		}
+
+		if azureSettings.CertificateExpiryWarningWindow > 0 {
+			add(CertificateExpiryWarningWindow, azureSettings.CertificateExpiryWarningWindow.String())
+		}
+
+		if len(azureSettings.ForwardSettingsPlugins) > 0 {
+			add(ForwardSettingsPlugins, strings.Join(azureSettings.ForwardSettingsPlugins, ","))
+		}
 	}
 
-	return envs
+	return vars
+}
+
+// getCustomCloudsJSON returns the JSON the custom clouds were read from, or the serialized custom clouds
+// if they were set programmatically
+func getCustomCloudsJSON(azureSettings *AzureSettings) string {
+	if azureSettings.CustomCloudListJSON != "" || len(azureSettings.CustomCloudList) == 0 {
+		return azureSettings.CustomCloudListJSON
+	}
+	customCloudsJSON, err := json.Marshal(azureSettings.CustomCloudList)
+	if err != nil {
+		return ""
+	}
+	return string(customCloudsJSON)
 }
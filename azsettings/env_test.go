@@ -1,8 +1,15 @@
 package azsettings
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -143,6 +150,20 @@ func TestReadFromEnv(t *testing.T) {
 
 			assert.Equal(t, "", azureSettings.ManagedIdentityClientId)
 		})
+
+		t.Run("should set allowed client IDs if variable is set", func(t *testing.T) {
+			unset1, err := setEnvVar("GFAZPL_MANAGED_IDENTITY_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset1()
+			unset2, err := setEnvVar("GFAZPL_MANAGED_IDENTITY_ALLOWED_CLIENT_IDS", "TestClientId1, TestClientId2,")
+			require.NoError(t, err)
+			defer unset2()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"TestClientId1", "TestClientId2"}, azureSettings.ManagedIdentityAllowedClientIds)
+		})
 	})
 
 	t.Run("workload identity", func(t *testing.T) {
@@ -262,6 +283,52 @@ func TestReadFromEnv(t *testing.T) {
 		})
 	})
 
+	t.Run("workload identity allowed client IDs", func(t *testing.T) {
+		t.Run("should set allowed client IDs if variable is set", func(t *testing.T) {
+			unset1, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset1()
+			unset2, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ALLOWED_CLIENT_IDS", "TestClientId1,TestClientId2")
+			require.NoError(t, err)
+			defer unset2()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			require.NotNil(t, azureSettings.WorkloadIdentitySettings)
+			assert.Equal(t, []string{"TestClientId1", "TestClientId2"}, azureSettings.WorkloadIdentitySettings.AllowedClientIds)
+		})
+	})
+
+	t.Run("workload identity cross-cloud", func(t *testing.T) {
+		t.Run("should enable cross-cloud if variable is set", func(t *testing.T) {
+			unset1, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset1()
+			unset2, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_CROSS_CLOUD_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset2()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			require.NotNil(t, azureSettings.WorkloadIdentitySettings)
+			assert.True(t, azureSettings.WorkloadIdentitySettings.CrossCloudEnabled)
+		})
+
+		t.Run("should return error if variable is invalid", func(t *testing.T) {
+			unset1, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset1()
+			unset2, err := setEnvVar("GFAZPL_WORKLOAD_IDENTITY_CROSS_CLOUD_ENABLED", "invalid")
+			require.NoError(t, err)
+			defer unset2()
+
+			_, err = ReadFromEnv()
+			assert.Error(t, err)
+		})
+	})
+
 	t.Run("when user identity enabled", func(t *testing.T) {
 		unset, err := setEnvVar("GFAZPL_USER_IDENTITY_ENABLED", "true")
 		require.NoError(t, err)
@@ -435,6 +502,83 @@ func TestReadFromEnv(t *testing.T) {
 			require.Nil(t, azureSettings.UserIdentityTokenEndpoint)
 		})
 	})
+
+	t.Run("client assertion", func(t *testing.T) {
+		t.Run("should enable client assertion credentials if variable is set", func(t *testing.T) {
+			unset, err := setEnvVar("GFAZPL_CLIENT_ASSERTION_CREDENTIALS_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.True(t, azureSettings.ClientAssertionCredentialsEnabled)
+			assert.Nil(t, azureSettings.ClientAssertionAllowedFiles)
+		})
+
+		t.Run("should set allowed client assertion files if variable is set", func(t *testing.T) {
+			unset, err := setEnvVar("GFAZPL_CLIENT_ASSERTION_CREDENTIALS_ENABLED", "true")
+			require.NoError(t, err)
+			defer unset()
+			unsetFiles, err := setEnvVar("GFAZPL_CLIENT_ASSERTION_ALLOWED_FILES", "/var/run/secrets/assertion1, /var/run/secrets/assertion2")
+			require.NoError(t, err)
+			defer unsetFiles()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"/var/run/secrets/assertion1", "/var/run/secrets/assertion2"}, azureSettings.ClientAssertionAllowedFiles)
+		})
+
+		t.Run("should disable client assertion credentials if variable is not set", func(t *testing.T) {
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.False(t, azureSettings.ClientAssertionCredentialsEnabled)
+		})
+	})
+
+	t.Run("should set forward settings plugins if variable is set", func(t *testing.T) {
+		unset, err := setEnvVar("GFAZPL_FORWARD_SETTINGS_PLUGINS", "grafana-azure-monitor-datasource, prometheus")
+		require.NoError(t, err)
+		defer unset()
+
+		azureSettings, err := ReadFromEnv()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"grafana-azure-monitor-datasource", "prometheus"}, azureSettings.ForwardSettingsPlugins)
+	})
+
+	t.Run("certificate expiry warning window", func(t *testing.T) {
+		t.Run("should read certificate expiry warning window if variable is set", func(t *testing.T) {
+			unset, err := setEnvVar("GFAZPL_CERTIFICATE_EXPIRY_WARNING_WINDOW", "168h")
+			require.NoError(t, err)
+			defer unset()
+
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.Equal(t, 7*24*time.Hour, azureSettings.CertificateExpiryWarningWindow)
+			assert.Equal(t, 7*24*time.Hour, azureSettings.GetCertificateExpiryWarningWindow())
+		})
+
+		t.Run("should use default certificate expiry warning window if variable is not set", func(t *testing.T) {
+			azureSettings, err := ReadFromEnv()
+			require.NoError(t, err)
+
+			assert.Equal(t, time.Duration(0), azureSettings.CertificateExpiryWarningWindow)
+			assert.Equal(t, DefaultCertificateExpiryWarningWindow, azureSettings.GetCertificateExpiryWarningWindow())
+		})
+
+		t.Run("should return error if certificate expiry warning window is invalid", func(t *testing.T) {
+			unset, err := setEnvVar("GFAZPL_CERTIFICATE_EXPIRY_WARNING_WINDOW", "30 days")
+			require.NoError(t, err)
+			defer unset()
+
+			_, err = ReadFromEnv()
+			assert.Error(t, err)
+		})
+	})
 }
 
 func TestWriteToEnvStr(t *testing.T) {
@@ -496,6 +640,49 @@ func TestWriteToEnvStr(t *testing.T) {
 		assert.Equal(t, "GFAZPL_MANAGED_IDENTITY_CLIENT_ID=c2e68b2e", envs[1])
 	})
 
+	t.Run("should return managed identity allowed client IDs if provided", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ManagedIdentityEnabled:          true,
+			ManagedIdentityAllowedClientIds: []string{"c2e68b2e", "5a8b3e1c"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_MANAGED_IDENTITY_ENABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_MANAGED_IDENTITY_ALLOWED_CLIENT_IDS=c2e68b2e,5a8b3e1c", envs[1])
+	})
+
+	t.Run("should return workload identity allowed client IDs if provided", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				AllowedClientIds: []string{"c2e68b2e", "5a8b3e1c"},
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_ENABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_ALLOWED_CLIENT_IDS=c2e68b2e,5a8b3e1c", envs[1])
+	})
+
+	t.Run("should return workload identity cross-cloud if enabled", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				CrossCloudEnabled: true,
+			},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 2)
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_ENABLED=true", envs[0])
+		assert.Equal(t, "GFAZPL_WORKLOAD_IDENTITY_CROSS_CLOUD_ENABLED=true", envs[1])
+	})
+
 	t.Run("should not return managed identity client ID if not enabled", func(t *testing.T) {
 		azureSettings := &AzureSettings{
 			ManagedIdentityClientId: "c2e68b2e",
@@ -649,6 +836,227 @@ func TestWriteToEnvStr(t *testing.T) {
 
 		assert.Contains(t, envs, "GFAZPL_USER_IDENTITY_ASSERTION=username")
 	})
+
+	t.Run("should return client assertion credentials set if enabled", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ClientAssertionCredentialsEnabled: true,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_CLIENT_ASSERTION_CREDENTIALS_ENABLED=true", envs[0])
+	})
+
+	t.Run("should return allowed client assertion files if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ClientAssertionCredentialsEnabled: true,
+			ClientAssertionAllowedFiles:       []string{"/var/run/secrets/assertion1", "/var/run/secrets/assertion2"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		assert.Contains(t, envs, "GFAZPL_CLIENT_ASSERTION_ALLOWED_FILES=/var/run/secrets/assertion1,/var/run/secrets/assertion2")
+	})
+
+	t.Run("should return certificate expiry warning window if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			CertificateExpiryWarningWindow: 7 * 24 * time.Hour,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_CERTIFICATE_EXPIRY_WARNING_WINDOW=168h0m0s", envs[0])
+	})
+
+	t.Run("should return custom clouds JSON if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{}
+		err := azureSettings.SetCustomClouds(`[{"name":"CustomCloud","displayName":"Custom","aadAuthority":"https://login.example.com/"}]`)
+		require.NoError(t, err)
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, `GFAZPL_AZURE_CLOUDS_CONFIG=[{"name":"CustomCloud","displayName":"Custom","aadAuthority":"https://login.example.com/"}]`, envs[0])
+	})
+
+	t.Run("should return serialized custom clouds if JSON not set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			CustomCloudList: []*AzureCloudSettings{{Name: "CustomCloud", DisplayName: "Custom", AadAuthority: "https://login.example.com/"}},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, `GFAZPL_AZURE_CLOUDS_CONFIG=[{"name":"CustomCloud","displayName":"Custom","aadAuthority":"https://login.example.com/","properties":null}]`, envs[0])
+	})
+
+	t.Run("should return Entra password credentials enabled if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			AzureEntraPasswordCredentialsEnabled: true,
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_AZURE_ENTRA_PASSWORD_CREDENTIALS_ENABLED=true", envs[0])
+	})
+
+	t.Run("should return forward settings plugins if set", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			ForwardSettingsPlugins: []string{"grafana-azure-monitor-datasource", "prometheus"},
+		}
+
+		envs := WriteToEnvStr(azureSettings)
+
+		require.Len(t, envs, 1)
+		assert.Equal(t, "GFAZPL_FORWARD_SETTINGS_PLUGINS=grafana-azure-monitor-datasource,prometheus", envs[0])
+	})
+
+	t.Run("should read same settings as written", func(t *testing.T) {
+		defer clearSettingsEnv()
+
+		property := func(generated generatedSettings) bool {
+			clearSettingsEnv()
+			for _, env := range WriteToEnvStr(generated.AzureSettings) {
+				key, value, _ := strings.Cut(env, "=")
+				if err := os.Setenv(key, value); err != nil {
+					return false
+				}
+			}
+
+			result, err := ReadFromEnv()
+			if generated.isUserIdentityIncomplete() {
+				return assert.Error(t, err)
+			}
+
+			expected := generated.readBack()
+			if expected.Cloud == "" {
+				expected.Cloud = AzurePublic
+			}
+			return assert.NoError(t, err) && assert.Equal(t, expected, result)
+		}
+
+		err := quick.Check(property, &quick.Config{MaxCount: 500})
+		assert.NoError(t, err)
+	})
+}
+
+// generatedSettings are random settings which are valid in the Grafana config, the fields of disabled
+// authentication methods aren't set
+type generatedSettings struct {
+	*AzureSettings
+}
+
+func (generatedSettings) Generate(r *rand.Rand, _ int) reflect.Value {
+	randomBool := func() bool {
+		return r.Intn(2) == 1
+	}
+	randomString := func(prefix string) string {
+		if randomBool() {
+			return ""
+		}
+		return fmt.Sprintf("%s-%d", prefix, r.Intn(1000))
+	}
+	randomList := func(prefix string) []string {
+		var list []string
+		for i := r.Intn(3); i > 0; i-- {
+			list = append(list, fmt.Sprintf("%s-%d", prefix, r.Intn(1000)))
+		}
+		return list
+	}
+
+	settings := &AzureSettings{
+		AzureAuthEnabled:                     randomBool(),
+		Cloud:                                []string{"", AzurePublic, AzureChina, AzureUSGovernment, "CustomCloud"}[r.Intn(5)],
+		AzureEntraPasswordCredentialsEnabled: randomBool(),
+		CertificateExpiryWarningWindow:       time.Duration(r.Intn(1000)) * time.Hour,
+		ForwardSettingsPlugins:               randomList("plugin"),
+	}
+
+	if randomBool() {
+		settings.CustomCloudList = []*AzureCloudSettings{
+			{
+				Name:         "CustomCloud",
+				DisplayName:  "Custom Cloud",
+				AadAuthority: fmt.Sprintf("https://login-%d.example.com/", r.Intn(1000)),
+				Properties: map[string]string{
+					"resourceManager": "https://management.example.com",
+				},
+			},
+		}
+		if randomBool() {
+			customCloudsJSON, _ := json.Marshal(settings.CustomCloudList)
+			settings.CustomCloudListJSON = string(customCloudsJSON)
+		}
+	}
+
+	if randomBool() {
+		settings.ClientAssertionCredentialsEnabled = true
+		settings.ClientAssertionAllowedFiles = randomList("/var/run/secrets/assertion")
+	}
+
+	if randomBool() {
+		settings.ManagedIdentityEnabled = true
+		settings.ManagedIdentityClientId = randomString("mi-client")
+		settings.ManagedIdentityAllowedClientIds = randomList("mi-allowed")
+	}
+
+	if randomBool() {
+		settings.WorkloadIdentityEnabled = true
+		settings.WorkloadIdentitySettings = &WorkloadIdentitySettings{
+			TenantId:          randomString("wi-tenant"),
+			ClientId:          randomString("wi-client"),
+			TokenFile:         randomString("/var/run/secrets/token"),
+			AllowedClientIds:  randomList("wi-allowed"),
+			CrossCloudEnabled: randomBool(),
+		}
+	}
+
+	if randomBool() {
+		settings.UserIdentityEnabled = true
+		settings.UserIdentityFallbackCredentialsEnabled = randomBool()
+		settings.UserIdentityTokenEndpoint = &TokenEndpointSettings{
+			TokenUrl:                    randomString("https://login.example.com/oauth2/v2.0/token"),
+			ClientAuthentication:        []string{"client_secret_post", "managed_identity"}[r.Intn(2)],
+			ClientId:                    randomString("ui-client"),
+			ClientSecret:                randomString("ui-secret"),
+			ManagedIdentityClientId:     randomString("ui-mi-client"),
+			FederatedCredentialAudience: randomString("api://AzureADTokenExchange"),
+			UsernameAssertion:           randomBool(),
+		}
+	}
+
+	return reflect.ValueOf(generatedSettings{settings})
+}
+
+// readBack returns the settings expected to be read back after they are written, the custom clouds
+// are written as JSON
+func (generated generatedSettings) readBack() *AzureSettings {
+	expected := *generated.AzureSettings
+	if len(expected.CustomCloudList) > 0 && expected.CustomCloudListJSON == "" {
+		customCloudsJSON, _ := json.Marshal(expected.CustomCloudList)
+		expected.CustomCloudListJSON = string(customCloudsJSON)
+	}
+	return &expected
+}
+
+// isUserIdentityIncomplete returns true if user identity is enabled without the settings required
+// by ReadFromEnv
+func (generated generatedSettings) isUserIdentityIncomplete() bool {
+	tokenEndpoint := generated.UserIdentityTokenEndpoint
+	return generated.UserIdentityEnabled && (tokenEndpoint.TokenUrl == "" || tokenEndpoint.ClientId == "")
+}
+
+// clearSettingsEnv unsets all the environment variables of the settings
+func clearSettingsEnv() {
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, "GFAZPL_") || key == fallbackAzureCloud || key == fallbackManagedIdentityEnabled || key == fallbackManagedIdentityClientId {
+			_ = os.Unsetenv(key)
+		}
+	}
 }
 
 type unsetFunc = func()
@@ -0,0 +1,89 @@
+package azsettings
+
+import (
+	"log/slog"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/internal/redact"
+)
+
+// The settings are formatted with their secrets redacted (see internal/redact).
+
+func (settings AzureSettings) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "AzureAuthEnabled", Value: settings.AzureAuthEnabled},
+		{Name: "Cloud", Value: settings.Cloud},
+		{Name: "ManagedIdentityEnabled", Value: settings.ManagedIdentityEnabled},
+		{Name: "ManagedIdentityClientId", Value: settings.ManagedIdentityClientId},
+		{Name: "ManagedIdentityAllowedClientIds", Value: settings.ManagedIdentityAllowedClientIds},
+		{Name: "WorkloadIdentityEnabled", Value: settings.WorkloadIdentityEnabled},
+		{Name: "WorkloadIdentitySettings", Value: settings.WorkloadIdentitySettings},
+		{Name: "UserIdentityEnabled", Value: settings.UserIdentityEnabled},
+		{Name: "UserIdentityTokenEndpoint", Value: settings.UserIdentityTokenEndpoint},
+		{Name: "UserIdentityFallbackCredentialsEnabled", Value: settings.UserIdentityFallbackCredentialsEnabled},
+		{Name: "ForwardSettingsPlugins", Value: settings.ForwardSettingsPlugins},
+		{Name: "CustomCloudList", Value: settings.CustomCloudList},
+		{Name: "CustomCloudListJSON", Value: settings.CustomCloudListJSON},
+		{Name: "AzureEntraPasswordCredentialsEnabled", Value: settings.AzureEntraPasswordCredentialsEnabled},
+		{Name: "ClientAssertionCredentialsEnabled", Value: settings.ClientAssertionCredentialsEnabled},
+		{Name: "ClientAssertionAllowedFiles", Value: settings.ClientAssertionAllowedFiles},
+		{Name: "CertificateExpiryWarningWindow", Value: settings.CertificateExpiryWarningWindow},
+	}
+}
+
+func (settings AzureSettings) String() string {
+	return redact.String(settings.redactedFields()...)
+}
+
+func (settings AzureSettings) GoString() string {
+	return redact.GoString("azsettings.AzureSettings", settings.redactedFields()...)
+}
+
+func (settings AzureSettings) LogValue() slog.Value {
+	return redact.LogValue(settings.redactedFields()...)
+}
+
+func (settings WorkloadIdentitySettings) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "TenantId", Value: settings.TenantId},
+		{Name: "ClientId", Value: settings.ClientId},
+		{Name: "TokenFile", Value: settings.TokenFile},
+		{Name: "AllowedClientIds", Value: settings.AllowedClientIds},
+		{Name: "CrossCloudEnabled", Value: settings.CrossCloudEnabled},
+	}
+}
+
+func (settings WorkloadIdentitySettings) String() string {
+	return redact.String(settings.redactedFields()...)
+}
+
+func (settings WorkloadIdentitySettings) GoString() string {
+	return redact.GoString("azsettings.WorkloadIdentitySettings", settings.redactedFields()...)
+}
+
+func (settings WorkloadIdentitySettings) LogValue() slog.Value {
+	return redact.LogValue(settings.redactedFields()...)
+}
+
+func (settings TokenEndpointSettings) redactedFields() []redact.Field {
+	return []redact.Field{
+		{Name: "TokenUrl", Value: settings.TokenUrl},
+		{Name: "ClientAuthentication", Value: settings.ClientAuthentication},
+		{Name: "ClientId", Value: settings.ClientId},
+		redact.Secret("ClientSecret", settings.ClientSecret),
+		{Name: "ManagedIdentityClientId", Value: settings.ManagedIdentityClientId},
+		{Name: "FederatedCredentialAudience", Value: settings.FederatedCredentialAudience},
+		{Name: "UsernameAssertion", Value: settings.UsernameAssertion},
+	}
+}
+
+func (settings TokenEndpointSettings) String() string {
+	return redact.String(settings.redactedFields()...)
+}
+
+func (settings TokenEndpointSettings) GoString() string {
+	return redact.GoString("azsettings.TokenEndpointSettings", settings.redactedFields()...)
+}
+
+func (settings TokenEndpointSettings) LogValue() slog.Value {
+	return redact.LogValue(settings.redactedFields()...)
+}
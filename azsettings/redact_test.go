@@ -0,0 +1,79 @@
+package azsettings
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRedaction(t *testing.T) {
+	settings := &AzureSettings{
+		Cloud:               AzurePublic,
+		UserIdentityEnabled: true,
+		UserIdentityTokenEndpoint: &TokenEndpointSettings{
+			TokenUrl:     "https://login.microsoftonline.com/TENANT-ID/oauth2/v2.0/token",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-SECRET",
+		},
+		WorkloadIdentityEnabled: true,
+		WorkloadIdentitySettings: &WorkloadIdentitySettings{
+			TenantId: "WI-TENANT-ID",
+			ClientId: "WI-CLIENT-ID",
+		},
+	}
+
+	t.Run("should redact secrets when formatted", func(t *testing.T) {
+		for _, value := range []interface{}{settings, *settings, settings.UserIdentityTokenEndpoint, *settings.UserIdentityTokenEndpoint} {
+			for _, format := range []string{"%v", "%+v", "%#v"} {
+				formatted := fmt.Sprintf(format, value)
+
+				assert.NotContains(t, formatted, "FAKE-SECRET")
+				assert.Contains(t, formatted, "CLIENT-ID")
+				assert.Contains(t, formatted, "[REDACTED]")
+			}
+		}
+	})
+
+	t.Run("should format nested settings", func(t *testing.T) {
+		formatted := fmt.Sprintf("%+v", settings)
+
+		assert.Contains(t, formatted, "Cloud:AzureCloud")
+		assert.Contains(t, formatted, "WorkloadIdentitySettings:{TenantId:WI-TENANT-ID ClientId:WI-CLIENT-ID TokenFile: AllowedClientIds:[] CrossCloudEnabled:false}")
+	})
+
+	t.Run("should format settings as Go syntax", func(t *testing.T) {
+		formatted := fmt.Sprintf("%#v", settings.UserIdentityTokenEndpoint)
+
+		assert.Equal(t, `azsettings.TokenEndpointSettings{TokenUrl:"https://login.microsoftonline.com/TENANT-ID/oauth2/v2.0/token", ClientAuthentication:"", ClientId:"CLIENT-ID", ClientSecret:"[REDACTED]", ManagedIdentityClientId:"", FederatedCredentialAudience:"", UsernameAssertion:false}`, formatted)
+	})
+
+	t.Run("should format settings without nested settings", func(t *testing.T) {
+		formatted := fmt.Sprintf("%+v", &AzureSettings{})
+
+		assert.Contains(t, formatted, "UserIdentityTokenEndpoint:<nil>")
+	})
+
+	t.Run("should redact secrets when logged", func(t *testing.T) {
+		var textBuf, jsonBuf bytes.Buffer
+		slog.New(slog.NewTextHandler(&textBuf, nil)).Info("settings", "settings", settings)
+		slog.New(slog.NewJSONHandler(&jsonBuf, nil)).Info("settings", "settings", settings)
+
+		for _, logged := range []string{textBuf.String(), jsonBuf.String()} {
+			assert.NotContains(t, logged, "FAKE-SECRET")
+			assert.Contains(t, logged, "CLIENT-ID")
+			assert.Contains(t, logged, "[REDACTED]")
+		}
+		assert.Contains(t, textBuf.String(), "settings.UserIdentityTokenEndpoint.ClientSecret=[REDACTED]")
+	})
+
+	t.Run("should log settings without nested settings", func(t *testing.T) {
+		var buf bytes.Buffer
+		slog.New(slog.NewTextHandler(&buf, nil)).Info("settings", "settings", &AzureSettings{})
+
+		assert.Contains(t, buf.String(), "settings.UserIdentityTokenEndpoint=<nil>")
+		assert.NotContains(t, buf.String(), "panicked")
+	})
+}
@@ -2,7 +2,10 @@ package azsettings
 
 import (
 	"context"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
@@ -12,6 +15,10 @@ type AzureSettings struct {
 	Cloud                   string
 	ManagedIdentityEnabled  bool
 	ManagedIdentityClientId string
+	// Client IDs of user-assigned managed identities which datasources are allowed to select, object IDs
+	// and resource IDs can be listed as well. Datasources can only use the managed identity configured
+	// in Grafana if not set.
+	ManagedIdentityAllowedClientIds []string
 
 	WorkloadIdentityEnabled  bool
 	WorkloadIdentitySettings *WorkloadIdentitySettings
@@ -27,12 +34,31 @@ type AzureSettings struct {
 	CustomCloudListJSON string
 
 	AzureEntraPasswordCredentialsEnabled bool
+
+	// Client assertion credentials read the assertion from a file on the Grafana instance
+	ClientAssertionCredentialsEnabled bool
+	// Paths of the client assertion files which datasources are allowed to read, no file can be read if not set
+	ClientAssertionAllowedFiles []string
+
+	// Period before the expiry of a datasource client certificate in which a warning is reported,
+	// DefaultCertificateExpiryWarningWindow is used if not set
+	CertificateExpiryWarningWindow time.Duration
 }
 
+// DefaultCertificateExpiryWarningWindow is the period before the expiry of a client certificate in which
+// a warning is reported if no other window is configured
+const DefaultCertificateExpiryWarningWindow = 30 * 24 * time.Hour
+
 type WorkloadIdentitySettings struct {
 	TenantId  string
 	ClientId  string
 	TokenFile string
+	// Client IDs which datasources are allowed to select, datasources can only use the client ID
+	// configured in Grafana if not set
+	AllowedClientIds []string
+	// Whether datasources are allowed to authenticate with app registrations in a cloud other than
+	// the cloud where Grafana is hosted
+	CrossCloudEnabled bool
 }
 
 type TokenEndpointSettings struct {
@@ -63,6 +89,88 @@ func (settings *AzureSettings) GetDefaultCloud() string {
 	return cloudName
 }
 
+// GetCertificateExpiryWarningWindow returns the period before the expiry of a client certificate in which
+// a warning is reported
+func (settings *AzureSettings) GetCertificateExpiryWarningWindow() time.Duration {
+	if settings.CertificateExpiryWarningWindow <= 0 {
+		return DefaultCertificateExpiryWarningWindow
+	}
+	return settings.CertificateExpiryWarningWindow
+}
+
+// IsManagedIdentityAllowed returns true if datasources are allowed to select the user-assigned managed
+// identity with the given client ID, object ID or resource ID. The managed identity configured in Grafana
+// is always allowed, other identities must be listed in the allowed client IDs.
+func (settings *AzureSettings) IsManagedIdentityAllowed(id string) bool {
+	if id == "" || strings.EqualFold(id, settings.ManagedIdentityClientId) {
+		return true
+	}
+	return isListed(settings.ManagedIdentityAllowedClientIds, id)
+}
+
+// IsWorkloadIdentityAllowed returns true if datasources are allowed to select the workload identity
+// with the given client ID. The client ID configured in Grafana is always allowed, other client IDs
+// must be listed in the allowed client IDs.
+func (settings *AzureSettings) IsWorkloadIdentityAllowed(clientId string) bool {
+	if clientId == "" {
+		return true
+	}
+	wiSettings := settings.WorkloadIdentitySettings
+	if wiSettings == nil {
+		return false
+	}
+	if strings.EqualFold(clientId, wiSettings.ClientId) {
+		return true
+	}
+	return isListed(wiSettings.AllowedClientIds, clientId)
+}
+
+// IsWorkloadIdentityCloudAllowed returns true if datasources are allowed to select the workload identity
+// in the given cloud, the cloud where Grafana is hosted is always allowed
+func (settings *AzureSettings) IsWorkloadIdentityCloudAllowed(cloudName string) bool {
+	if cloudName == "" || cloudName == settings.GetDefaultCloud() {
+		return true
+	}
+	return settings.WorkloadIdentitySettings != nil && settings.WorkloadIdentitySettings.CrossCloudEnabled
+}
+
+// IsClientAssertionFileAllowed returns true if datasources are allowed to read the client assertion
+// from the file with the given path, the path must be one of the allowed files
+func (settings *AzureSettings) IsClientAssertionFileAllowed(path string) bool {
+	if path == "" || !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	for _, allowed := range settings.ClientAssertionAllowedFiles {
+		if filepath.Clean(allowed) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// isListed returns true if the allowlist contains the given value (compared case-insensitively
+// as client IDs are GUIDs)
+func isListed(allowlist []string, value string) bool {
+	for _, allowed := range allowlist {
+		if strings.EqualFold(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseList parses a comma-separated list of values
+func parseList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // Changes here are dependant on https://github.com/grafana/grafana/tree/main/pkg/plugins/envvars/envvars.go#L148
 func ReadFromContext(ctx context.Context) (*AzureSettings, bool) {
 	cfg := backend.GrafanaConfigFromContext(ctx)
@@ -100,6 +208,9 @@ func ReadFromContext(ctx context.Context) (*AzureSettings, bool) {
 		if v := cfg.Get(ManagedIdentityClientID); v != "" {
 			settings.ManagedIdentityClientId = v
 		}
+		if v := cfg.Get(ManagedIdentityAllowedClientIDs); v != "" {
+			settings.ManagedIdentityAllowedClientIds = parseList(v)
+		}
 	}
 
 	if v := cfg.Get(UserIdentityEnabled); v == strconv.FormatBool(true) {
@@ -152,6 +263,12 @@ func ReadFromContext(ctx context.Context) (*AzureSettings, bool) {
 		if v := cfg.Get(WorkloadIdentityTokenFile); v != "" {
 			settings.WorkloadIdentitySettings.TokenFile = v
 		}
+		if v := cfg.Get(WorkloadIdentityAllowedClientIDs); v != "" {
+			settings.WorkloadIdentitySettings.AllowedClientIds = parseList(v)
+		}
+		if v := cfg.Get(WorkloadIdentityCrossCloudEnabled); v == strconv.FormatBool(true) {
+			settings.WorkloadIdentitySettings.CrossCloudEnabled = true
+		}
 	}
 
 	if v := cfg.Get(AzureEntraPasswordCredentialsEnabled); v == strconv.FormatBool(true) {
@@ -159,9 +276,43 @@ func ReadFromContext(ctx context.Context) (*AzureSettings, bool) {
 		hasSettings = true
 	}
 
+	if v := cfg.Get(ClientAssertionCredentialsEnabled); v == strconv.FormatBool(true) {
+		settings.ClientAssertionCredentialsEnabled = true
+		hasSettings = true
+
+		if v := cfg.Get(ClientAssertionAllowedFiles); v != "" {
+			settings.ClientAssertionAllowedFiles = parseList(v)
+		}
+	}
+
+	if v := cfg.Get(CertificateExpiryWarningWindow); v != "" {
+		if window, err := time.ParseDuration(v); err != nil {
+			backend.Logger.Error("Error parsing certificate expiry warning window", "error", err)
+		} else {
+			settings.CertificateExpiryWarningWindow = window
+			hasSettings = true
+		}
+	}
+
+	if v := cfg.Get(ForwardSettingsPlugins); v != "" {
+		settings.ForwardSettingsPlugins = parseList(v)
+		hasSettings = true
+	}
+
 	return settings, hasSettings
 }
 
+// WriteToGrafanaConfig returns the settings in the form of the Grafana config passed to plugins
+// (see backend.NewGrafanaCfg), the settings read back by ReadFromContext are equal to the written ones,
+// except that CustomCloudList is written as JSON, so CustomCloudListJSON is set when read back
+func WriteToGrafanaConfig(azureSettings *AzureSettings) map[string]string {
+	cfg := map[string]string{}
+	for _, v := range getSettingsVars(azureSettings) {
+		cfg[v.key] = v.value
+	}
+	return cfg
+}
+
 func ReadSettings(ctx context.Context) (*AzureSettings, error) {
 	azSettings, exists := ReadFromContext(ctx)
 
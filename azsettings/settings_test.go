@@ -3,8 +3,11 @@ package azsettings
 import (
 	"context"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,6 +50,7 @@ func TestSettingsFromContext(t *testing.T) {
 					AzureAuthEnabled:                        "true",
 					ManagedIdentityEnabled:                  "true",
 					ManagedIdentityClientID:                 "mock_managed_identity_client_id",
+					ManagedIdentityAllowedClientIDs:         "mock_allowed_client_id1,mock_allowed_client_id2",
 					UserIdentityEnabled:                     "true",
 					UserIdentityClientAuthentication:        "mock_user_identity_client_authentication",
 					UserIdentityClientID:                    "mock_user_identity_client_id",
@@ -60,12 +64,18 @@ func TestSettingsFromContext(t *testing.T) {
 					WorkloadIdentityClientID:                "mock_workload_identity_client_id",
 					WorkloadIdentityTenantID:                "mock_workload_identity_tenant_id",
 					WorkloadIdentityTokenFile:               "mock_workload_identity_token_file",
+					WorkloadIdentityAllowedClientIDs:        "mock_allowed_client_id3",
+					WorkloadIdentityCrossCloudEnabled:       "true",
+					ClientAssertionCredentialsEnabled:       "true",
+					ClientAssertionAllowedFiles:             "/var/run/secrets/assertion1,/var/run/secrets/assertion2",
+					CertificateExpiryWarningWindow:          "336h",
 				}),
 				expectedAzure: &AzureSettings{
 					Cloud:                                  AzurePublic,
 					AzureAuthEnabled:                       true,
 					ManagedIdentityEnabled:                 true,
 					ManagedIdentityClientId:                "mock_managed_identity_client_id",
+					ManagedIdentityAllowedClientIds:        []string{"mock_allowed_client_id1", "mock_allowed_client_id2"},
 					UserIdentityEnabled:                    true,
 					UserIdentityFallbackCredentialsEnabled: true,
 					UserIdentityTokenEndpoint: &TokenEndpointSettings{
@@ -79,10 +89,15 @@ func TestSettingsFromContext(t *testing.T) {
 					},
 					WorkloadIdentityEnabled: true,
 					WorkloadIdentitySettings: &WorkloadIdentitySettings{
-						ClientId:  "mock_workload_identity_client_id",
-						TenantId:  "mock_workload_identity_tenant_id",
-						TokenFile: "mock_workload_identity_token_file",
+						ClientId:          "mock_workload_identity_client_id",
+						TenantId:          "mock_workload_identity_tenant_id",
+						TokenFile:         "mock_workload_identity_token_file",
+						AllowedClientIds:  []string{"mock_allowed_client_id3"},
+						CrossCloudEnabled: true,
 					},
+					ClientAssertionCredentialsEnabled: true,
+					ClientAssertionAllowedFiles:       []string{"/var/run/secrets/assertion1", "/var/run/secrets/assertion2"},
+					CertificateExpiryWarningWindow:    14 * 24 * time.Hour,
 				},
 				expectedHasSettings: true,
 			},
@@ -245,3 +260,157 @@ func TestReadSettings(t *testing.T) {
 	})
 
 }
+
+func TestIsIdentityAllowed(t *testing.T) {
+	t.Run("should allow only default managed identity if allowlist not set", func(t *testing.T) {
+		settings := &AzureSettings{}
+
+		require.True(t, settings.IsManagedIdentityAllowed(""))
+		require.False(t, settings.IsManagedIdentityAllowed("any_client_id"))
+
+		settings.ManagedIdentityClientId = "default_client_id"
+		require.True(t, settings.IsManagedIdentityAllowed(""))
+		require.True(t, settings.IsManagedIdentityAllowed("DEFAULT_CLIENT_ID"))
+		require.False(t, settings.IsManagedIdentityAllowed("any_client_id"))
+	})
+
+	t.Run("should allow listed managed identities by object ID and resource ID", func(t *testing.T) {
+		settings := &AzureSettings{
+			ManagedIdentityAllowedClientIds: []string{"allowed_object_id", "/subscriptions/SUB/resourceGroups/RG/providers/Microsoft.ManagedIdentity/userAssignedIdentities/ALLOWED"},
+		}
+
+		require.True(t, settings.IsManagedIdentityAllowed("allowed_object_id"))
+		require.True(t, settings.IsManagedIdentityAllowed("/subscriptions/sub/resourcegroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/allowed"))
+		require.False(t, settings.IsManagedIdentityAllowed("other_object_id"))
+	})
+
+	t.Run("should allow only listed managed identities and the default identity", func(t *testing.T) {
+		settings := &AzureSettings{
+			ManagedIdentityClientId:         "default_client_id",
+			ManagedIdentityAllowedClientIds: []string{"ALLOWED_CLIENT_ID"},
+		}
+
+		require.True(t, settings.IsManagedIdentityAllowed(""))
+		require.True(t, settings.IsManagedIdentityAllowed("default_client_id"))
+		require.True(t, settings.IsManagedIdentityAllowed("allowed_client_id"))
+		require.False(t, settings.IsManagedIdentityAllowed("other_client_id"))
+	})
+
+	t.Run("should allow only default workload identity if allowlist not set", func(t *testing.T) {
+		settings := &AzureSettings{}
+
+		require.True(t, settings.IsWorkloadIdentityAllowed(""))
+		require.False(t, settings.IsWorkloadIdentityAllowed("any_client_id"))
+
+		settings.WorkloadIdentitySettings = &WorkloadIdentitySettings{ClientId: "default_client_id"}
+		require.True(t, settings.IsWorkloadIdentityAllowed(""))
+		require.True(t, settings.IsWorkloadIdentityAllowed("default_client_id"))
+		require.False(t, settings.IsWorkloadIdentityAllowed("any_client_id"))
+	})
+
+	t.Run("should allow only listed workload identities and the default identity", func(t *testing.T) {
+		settings := &AzureSettings{
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				ClientId:         "default_client_id",
+				AllowedClientIds: []string{"allowed_client_id"},
+			},
+		}
+
+		require.True(t, settings.IsWorkloadIdentityAllowed(""))
+		require.True(t, settings.IsWorkloadIdentityAllowed("default_client_id"))
+		require.True(t, settings.IsWorkloadIdentityAllowed("allowed_client_id"))
+		require.False(t, settings.IsWorkloadIdentityAllowed("other_client_id"))
+	})
+
+	t.Run("should allow workload identity only in cloud of Grafana if cross-cloud not enabled", func(t *testing.T) {
+		settings := &AzureSettings{
+			Cloud:                    AzureChina,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{},
+		}
+
+		require.True(t, settings.IsWorkloadIdentityCloudAllowed(""))
+		require.True(t, settings.IsWorkloadIdentityCloudAllowed(AzureChina))
+		require.False(t, settings.IsWorkloadIdentityCloudAllowed(AzurePublic))
+	})
+
+	t.Run("should allow workload identity in any cloud if cross-cloud enabled", func(t *testing.T) {
+		settings := &AzureSettings{
+			Cloud: AzureChina,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				CrossCloudEnabled: true,
+			},
+		}
+
+		require.True(t, settings.IsWorkloadIdentityCloudAllowed(AzurePublic))
+	})
+}
+
+func TestIsClientAssertionFileAllowed(t *testing.T) {
+	t.Run("should not allow any file if allowlist not set", func(t *testing.T) {
+		settings := &AzureSettings{}
+
+		require.False(t, settings.IsClientAssertionFileAllowed(""))
+		require.False(t, settings.IsClientAssertionFileAllowed("/var/run/secrets/assertion"))
+	})
+
+	t.Run("should allow only listed files", func(t *testing.T) {
+		settings := &AzureSettings{
+			ClientAssertionAllowedFiles: []string{"/var/run/secrets/assertion"},
+		}
+
+		require.True(t, settings.IsClientAssertionFileAllowed("/var/run/secrets/assertion"))
+		require.True(t, settings.IsClientAssertionFileAllowed("/var/run/secrets/../secrets/assertion"))
+		require.False(t, settings.IsClientAssertionFileAllowed("/var/run/secrets/other"))
+		require.False(t, settings.IsClientAssertionFileAllowed("/etc/passwd"))
+	})
+
+	t.Run("should not allow relative paths", func(t *testing.T) {
+		settings := &AzureSettings{
+			ClientAssertionAllowedFiles: []string{"assertion"},
+		}
+
+		require.False(t, settings.IsClientAssertionFileAllowed("assertion"))
+	})
+}
+
+func TestWriteToGrafanaConfig(t *testing.T) {
+	t.Run("should return empty config if AzureSettings not set", func(t *testing.T) {
+		cfg := WriteToGrafanaConfig(nil)
+
+		require.Empty(t, cfg)
+	})
+
+	t.Run("should return config with same keys and values as environment variables", func(t *testing.T) {
+		azureSettings := &AzureSettings{
+			Cloud:                   AzureChina,
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &WorkloadIdentitySettings{
+				ClientId: "WI-CLIENT-ID",
+			},
+			ForwardSettingsPlugins: []string{"prometheus"},
+		}
+
+		cfg := WriteToGrafanaConfig(azureSettings)
+
+		require.Equal(t, map[string]string{
+			"GFAZPL_AZURE_CLOUD":                 AzureChina,
+			"GFAZPL_WORKLOAD_IDENTITY_ENABLED":   "true",
+			"GFAZPL_WORKLOAD_IDENTITY_CLIENT_ID": "WI-CLIENT-ID",
+			"GFAZPL_FORWARD_SETTINGS_PLUGINS":    "prometheus",
+		}, cfg)
+	})
+
+	t.Run("should read same settings as written", func(t *testing.T) {
+		property := func(generated generatedSettings) bool {
+			ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(WriteToGrafanaConfig(generated.AzureSettings)))
+
+			result, ok := ReadFromContext(ctx)
+			// Settings without any field set aren't reported as present
+			hasSettings := len(WriteToGrafanaConfig(generated.AzureSettings)) > 0
+			return assert.Equal(t, hasSettings, ok) && assert.Equal(t, generated.readBack(), result)
+		}
+
+		err := quick.Check(property, &quick.Config{MaxCount: 500})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,233 @@
+package aztokenprovider
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// parseClientCertificate parses the certificate chain and the private key of the client certificate credentials,
+// the format is detected from the certificate if it's empty or "auto"
+func parseClientCertificate(certificateFormat string, clientCertificate string, privateKey string, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	if strings.TrimSpace(clientCertificate) == "" {
+		return nil, nil, fmt.Errorf("no client certificate provided")
+	}
+
+	switch certificateFormat {
+	case "", "auto":
+		return parseDetectedCertificate(clientCertificate, privateKey, password)
+	case "pem":
+		return parsePemCertificate(clientCertificate, privateKey, password)
+	case "der":
+		certificateData, err := decodeBase64("client certificate", clientCertificate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return parseDerCertificate(certificateData, privateKey, password)
+	case "pfx":
+		certificateData, err := decodeBase64("client certificate", clientCertificate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return parsePfxCertificate(certificateData, password)
+	default:
+		return nil, nil, fmt.Errorf("certificate format '%s' not supported", certificateFormat)
+	}
+}
+
+func parseDetectedCertificate(clientCertificate string, privateKey string, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	if strings.Contains(clientCertificate, "-----BEGIN ") {
+		return parsePemCertificate(clientCertificate, privateKey, password)
+	}
+
+	certificateData, err := decodeBase64("client certificate", clientCertificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("client certificate is neither PEM nor base64 encoded DER or pfx: %w", err)
+	}
+
+	// Both DER certificates and pfx files are ASN.1 structures, a pfx file isn't a valid certificate
+	if _, err := x509.ParseCertificates(certificateData); err == nil {
+		return parseDerCertificate(certificateData, privateKey, password)
+	}
+	return parsePfxCertificate(certificateData, password)
+}
+
+// parsePemCertificate parses the PEM blocks of the certificate and the private key, the certificate may be
+// a bundle which holds the chain and the private key
+func parsePemCertificate(clientCertificate string, privateKey string, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	certs, key, err := parsePemBlocks("client certificate", []byte(clientCertificate), password)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no certificate found in the client certificate")
+	}
+
+	if strings.TrimSpace(privateKey) != "" {
+		if key != nil {
+			return nil, nil, fmt.Errorf("private key provided in both the client certificate and the private key")
+		}
+		if key, err = parsePrivateKey(privateKey, password); err != nil {
+			return nil, nil, err
+		}
+	}
+	if key == nil {
+		return nil, nil, fmt.Errorf("no private key found in the client certificate or the private key")
+	}
+
+	return certs, key, nil
+}
+
+func parseDerCertificate(certificateData []byte, privateKey string, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	certs, err := x509.ParseCertificates(certificateData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse DER client certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no certificate found in the client certificate")
+	}
+
+	if strings.TrimSpace(privateKey) == "" {
+		return nil, nil, fmt.Errorf("no private key provided for the DER client certificate")
+	}
+	key, err := parsePrivateKey(privateKey, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certs, key, nil
+}
+
+func parsePfxCertificate(certificateData []byte, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	key, cert, caCerts, err := pkcs12.DecodeChain(certificateData, password)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			if password == "" {
+				return nil, nil, fmt.Errorf("failed to parse pfx client certificate: the pfx file is protected with a password but no password provided")
+			}
+			return nil, nil, fmt.Errorf("failed to parse pfx client certificate: incorrect password")
+		}
+		return nil, nil, fmt.Errorf("failed to parse pfx client certificate: %w", err)
+	}
+
+	certs := append([]*x509.Certificate{cert}, caCerts...)
+	return certs, key, nil
+}
+
+// parsePrivateKey parses the private key given separately from the certificate, either PEM or base64 encoded DER
+func parsePrivateKey(privateKey string, password string) (crypto.PrivateKey, error) {
+	if strings.Contains(privateKey, "-----BEGIN ") {
+		certs, key, err := parsePemBlocks("private key", []byte(privateKey), password)
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) > 0 {
+			return nil, fmt.Errorf("private key shouldn't contain certificates")
+		}
+		if key == nil {
+			return nil, fmt.Errorf("no private key found in the private key")
+		}
+		return key, nil
+	}
+
+	keyData, err := decodeBase64("private key", privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("private key is neither PEM nor base64 encoded DER: %w", err)
+	}
+	key, err := parseDerPrivateKey(keyData, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return key, nil
+}
+
+func parsePemBlocks(name string, data []byte, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	var certs []*x509.Certificate
+	var key crypto.PrivateKey
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse certificate %d in the %s: %w", len(certs)+1, name, err)
+			}
+			certs = append(certs, cert)
+
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+			if key != nil {
+				return nil, nil, fmt.Errorf("the %s contains multiple private keys", name)
+			}
+			if _, ok := block.Headers["DEK-Info"]; ok {
+				return nil, nil, fmt.Errorf("failed to parse private key in the %s: legacy PEM encryption not supported, use encrypted PKCS#8", name)
+			}
+
+			var err error
+			if block.Type == "ENCRYPTED PRIVATE KEY" {
+				key, err = parseEncryptedPrivateKey(block.Bytes, password)
+			} else {
+				key, err = parseDerPrivateKey(block.Bytes, "")
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse private key in the %s: %w", name, err)
+			}
+		}
+	}
+
+	if len(certs) == 0 && key == nil {
+		return nil, nil, fmt.Errorf("no PEM certificate or private key found in the %s", name)
+	}
+	return certs, key, nil
+}
+
+// parseDerPrivateKey parses a PKCS#8, PKCS#1 or SEC 1 private key, the key is decrypted with the password
+// if it's an encrypted PKCS#8 key
+func parseDerPrivateKey(keyData []byte, password string) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(keyData); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(keyData); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(keyData); err == nil {
+		return key, nil
+	}
+	if password != "" {
+		return parseEncryptedPrivateKey(keyData, password)
+	}
+	return nil, fmt.Errorf("unsupported private key type, expected PKCS#8, PKCS#1 or SEC 1 key")
+}
+
+func parseEncryptedPrivateKey(keyData []byte, password string) (crypto.PrivateKey, error) {
+	if password == "" {
+		return nil, fmt.Errorf("the private key is encrypted but no password provided")
+	}
+	key, err := pkcs8.ParsePKCS8PrivateKey(keyData, []byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return key, nil
+}
+
+func decodeBase64(name string, value string) ([]byte, error) {
+	// Line breaks are commonly preserved when the value is copied from a file
+	value = strings.Join(strings.Fields(value), "")
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 %s: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,202 @@
+package aztokenprovider
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// CertificateInfo describes the client certificate of AzureClientCertificateCredentials.
+type CertificateInfo struct {
+	// SHA-1 thumbprint as shown in the app registration in Entra ID
+	Thumbprint       string
+	ThumbprintSHA256 string
+	Subject          string
+	Issuer           string
+	NotBefore        time.Time
+	NotAfter         time.Time
+	// Number of certificates in the chain, including the client certificate
+	ChainLength int
+	// Whether the private key matches the public key of the certificate
+	KeyMatches bool
+}
+
+// GetCertificateInfo parses the client certificate of the given credentials and returns its metadata,
+// without contacting Entra ID.
+func GetCertificateInfo(credentials *azcredentials.AzureClientCertificateCredentials) (*CertificateInfo, error) {
+	if credentials == nil {
+		return nil, fmt.Errorf("parameter 'credentials' cannot be nil")
+	}
+
+	certs, key, err := parseClientCertificate(credentials.CertificateFormat, credentials.ClientCertificate, credentials.PrivateKey, credentials.CertificatePassword)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := certs[0]
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+
+	return &CertificateInfo{
+		Thumbprint:       strings.ToUpper(hex.EncodeToString(sha1Sum[:])),
+		ThumbprintSHA256: strings.ToUpper(hex.EncodeToString(sha256Sum[:])),
+		Subject:          cert.Subject.String(),
+		Issuer:           cert.Issuer.String(),
+		NotBefore:        cert.NotBefore,
+		NotAfter:         cert.NotAfter,
+		ChainLength:      len(certs),
+		KeyMatches:       isKeyMatching(key, cert.PublicKey),
+	}, nil
+}
+
+func isKeyMatching(key crypto.PrivateKey, publicKey crypto.PublicKey) bool {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	keyPublic, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+	return keyPublic.Equal(publicKey)
+}
+
+// CertificateWarning reports a client certificate which expires within the warning window configured
+// in Grafana or has already expired.
+type CertificateWarning struct {
+	Thumbprint string
+	Subject    string
+	NotAfter   time.Time
+	Message    string
+}
+
+// GetCertificateWarnings returns the warnings about the client certificates of the credentials
+// the given provider was created with, as of the time of the call. The certificates are parsed
+// when the provider is created.
+func GetCertificateWarnings(provider AzureTokenProvider) []CertificateWarning {
+	switch p := provider.(type) {
+	case *serviceTokenProvider:
+		return p.certificates.getWarnings(time.Now())
+	case *userTokenProvider:
+		return p.certificates.getWarnings(time.Now())
+	default:
+		return nil
+	}
+}
+
+// GetCredentialsCertificateWarnings returns the warnings about the client certificates of the given
+// credentials as of the time of the call, e.g. for callers of azhttpclient.AzureMiddleware which creates
+// the provider internally. The certificates are parsed on each call.
+func GetCredentialsCertificateWarnings(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) []CertificateWarning {
+	if settings == nil || credentials == nil {
+		return nil
+	}
+	return newCertificateExpiryCheck(settings, credentials).getWarnings(time.Now())
+}
+
+type clientCertificate struct {
+	clientId string
+	info     *CertificateInfo
+}
+
+// certificateExpiryCheck checks the expiry of the client certificates of the credentials of a provider
+type certificateExpiryCheck struct {
+	certificates []clientCertificate
+	window       time.Duration
+}
+
+// newCertificateExpiryCheck returns the expiry check of the client certificates of the given credentials,
+// certificates which cannot be parsed are reported when the token retriever is initialized
+func newCertificateExpiryCheck(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) *certificateExpiryCheck {
+	return &certificateExpiryCheck{
+		certificates: getClientCertificates(credentials),
+		window:       settings.GetCertificateExpiryWarningWindow(),
+	}
+}
+
+func getClientCertificates(credentials azcredentials.AzureCredentials) []clientCertificate {
+	var certificates []clientCertificate
+	for _, certificateCredentials := range getCertificateCredentials(credentials) {
+		info, err := GetCertificateInfo(certificateCredentials)
+		if err != nil {
+			continue
+		}
+		certificates = append(certificates, clientCertificate{clientId: certificateCredentials.ClientId, info: info})
+	}
+	return certificates
+}
+
+// getWarnings returns warnings about the client certificates which expire within the warning window
+// of the given time
+func (check *certificateExpiryCheck) getWarnings(now time.Time) []CertificateWarning {
+	if check == nil {
+		return nil
+	}
+
+	var warnings []CertificateWarning
+	for _, certificate := range check.certificates {
+		info := certificate.info
+
+		var message string
+		if !now.Before(info.NotAfter) {
+			message = fmt.Sprintf("client certificate expired on %s", info.NotAfter.UTC().Format(time.RFC3339))
+		} else if info.NotAfter.Sub(now) <= check.window {
+			message = fmt.Sprintf("client certificate expires on %s", info.NotAfter.UTC().Format(time.RFC3339))
+		} else {
+			continue
+		}
+
+		warnings = append(warnings, CertificateWarning{
+			Thumbprint: info.Thumbprint,
+			Subject:    info.Subject,
+			NotAfter:   info.NotAfter,
+			Message:    message,
+		})
+	}
+
+	return warnings
+}
+
+// logWarnings logs the client certificates which expire within the warning window of the given time
+func (check *certificateExpiryCheck) logWarnings(now time.Time) {
+	for _, certificate := range check.certificates {
+		info := certificate.info
+		if !now.Before(info.NotAfter) {
+			backend.Logger.Warn("Azure client certificate expired", "clientId", certificate.clientId,
+				"thumbprint", info.Thumbprint, "subject", info.Subject, "notAfter", info.NotAfter)
+		} else if info.NotAfter.Sub(now) <= check.window {
+			backend.Logger.Warn("Azure client certificate expires soon", "clientId", certificate.clientId,
+				"thumbprint", info.Thumbprint, "subject", info.Subject, "notAfter", info.NotAfter)
+		}
+	}
+}
+
+// getCertificateCredentials returns the client certificate credentials used directly, in chained credentials
+// or as the service credentials of the user identity credentials
+func getCertificateCredentials(credentials azcredentials.AzureCredentials) []*azcredentials.AzureClientCertificateCredentials {
+	switch c := credentials.(type) {
+	case *azcredentials.AzureClientCertificateCredentials:
+		return []*azcredentials.AzureClientCertificateCredentials{c}
+	case *azcredentials.AzureChainedCredentials:
+		var result []*azcredentials.AzureClientCertificateCredentials
+		for _, chainedCredentials := range c.Credentials {
+			result = append(result, getCertificateCredentials(chainedCredentials)...)
+		}
+		return result
+	case *azcredentials.AadCurrentUserCredentials:
+		if c.ServiceCredentialsEnabled && c.ServiceCredentials != nil {
+			return getCertificateCredentials(c.ServiceCredentials)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+)
+
+type chainedTokenRetriever struct {
+	steps []*chainedRetrieverStep
+	// Index of the step which succeeded last, tried first on the next request
+	current atomic.Int32
+}
+
+type chainedRetrieverStep struct {
+	authType  string
+	retriever TokenRetriever
+	// Set if the credentials are unavailable in this Grafana instance or failed to initialize
+	err error
+}
+
+func getChainedTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureChainedCredentials) (TokenRetriever, error) {
+	if len(credentials.Credentials) == 0 {
+		return nil, fmt.Errorf("chained credentials should contain at least one credentials")
+	}
+
+	steps := make([]*chainedRetrieverStep, 0, len(credentials.Credentials))
+	for _, chainedCredentials := range credentials.Credentials {
+		if chainedCredentials == nil {
+			return nil, fmt.Errorf("chained credentials cannot be nil")
+		}
+		if !azcredentials.IsChainableCredentials(chainedCredentials) {
+			return nil, fmt.Errorf("the authentication type '%s' cannot be chained", chainedCredentials.AzureAuthType())
+		}
+
+		// Credentials which aren't enabled in this Grafana instance are skipped, so that the same
+		// chain can be used in different environments
+		retriever, err := getServiceTokenRetriever(settings, chainedCredentials)
+		steps = append(steps, &chainedRetrieverStep{
+			authType:  chainedCredentials.AzureAuthType(),
+			retriever: retriever,
+			err:       err,
+		})
+	}
+
+	c := &chainedTokenRetriever{steps: steps}
+	if err := c.unavailableError(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *chainedTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
+	keys := make([]string, 0, len(c.steps))
+	for _, step := range c.steps {
+		if step.retriever != nil {
+			keys = append(keys, step.retriever.GetCacheKey(grafanaMultiTenantId))
+		} else {
+			keys = append(keys, step.authType)
+		}
+	}
+	return fmt.Sprintf("azure|chained|%s|%s", hashSecret(strings.Join(keys, "\n")), grafanaMultiTenantId)
+}
+
+func (c *chainedTokenRetriever) Init() error {
+	for _, step := range c.steps {
+		if step.err == nil {
+			step.err = step.retriever.Init()
+		}
+	}
+	return c.unavailableError()
+}
+
+// unavailableError returns an error with the errors of all the steps if none of them is available
+func (c *chainedTokenRetriever) unavailableError() error {
+	var errs []error
+	for i, step := range c.steps {
+		if step.err == nil {
+			return nil
+		}
+		errs = append(errs, step.wrapError(i, step.err))
+	}
+	return fmt.Errorf("none of the chained credentials available: %w", errors.Join(errs...))
+}
+
+func (c *chainedTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	var errs []error
+	for _, i := range c.order() {
+		step := c.steps[i]
+		if step.err != nil {
+			errs = append(errs, step.wrapError(i, step.err))
+			continue
+		}
+
+		accessToken, err := step.retriever.GetAccessToken(ctx, scopes)
+		if err != nil {
+			errs = append(errs, step.wrapError(i, err))
+			continue
+		}
+
+		c.current.Store(int32(i))
+		return accessToken, nil
+	}
+
+	return nil, fmt.Errorf("failed to authenticate with chained credentials: %w", errors.Join(errs...))
+}
+
+// order returns indexes of the steps to try, starting with the step which succeeded last
+func (c *chainedTokenRetriever) order() []int {
+	current := int(c.current.Load())
+	order := make([]int, 0, len(c.steps))
+	order = append(order, current)
+	for i := range c.steps {
+		if i != current {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// Empty implementation
+func (c *chainedTokenRetriever) GetExpiry() *time.Time {
+	return nil
+}
+
+func (step *chainedRetrieverStep) wrapError(index int, err error) error {
+	return fmt.Errorf("credentials %d (%s): %w", index, step.authType, err)
+}
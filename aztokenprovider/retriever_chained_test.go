@@ -0,0 +1,271 @@
+package aztokenprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getChainedCredential(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud:                  azsettings.AzurePublic,
+		ManagedIdentityEnabled: true,
+	}
+
+	clientSecretCredentials := &azcredentials.AzureClientSecretCredentials{
+		AzureCloud:   azsettings.AzurePublic,
+		TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+		ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+		ClientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+	}
+
+	t.Run("should return chainedTokenRetriever with retrievers in order", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Credentials: []azcredentials.AzureCredentials{
+				&azcredentials.AzureManagedIdentityCredentials{},
+				clientSecretCredentials,
+			},
+		}
+
+		result, err := getChainedTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &chainedTokenRetriever{}, result)
+		retriever := (result).(*chainedTokenRetriever)
+
+		require.Len(t, retriever.steps, 2)
+		assert.IsType(t, &managedIdentityTokenRetriever{}, retriever.steps[0].retriever)
+		assert.IsType(t, &clientSecretTokenRetriever{}, retriever.steps[1].retriever)
+	})
+
+	t.Run("should skip credentials which aren't enabled", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Credentials: []azcredentials.AzureCredentials{
+				&azcredentials.AzureWorkloadIdentityCredentials{},
+				clientSecretCredentials,
+			},
+		}
+
+		result, err := getChainedTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		retriever := (result).(*chainedTokenRetriever)
+		require.Len(t, retriever.steps, 2)
+		assert.Nil(t, retriever.steps[0].retriever)
+		assert.ErrorContains(t, retriever.steps[0].err, "workload identity authentication is not enabled in Grafana config")
+	})
+
+	t.Run("should fail with error if none of the credentials enabled", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Credentials: []azcredentials.AzureCredentials{
+				&azcredentials.AzureWorkloadIdentityCredentials{},
+				&azcredentials.AzureEntraPasswordCredentials{},
+			},
+		}
+
+		_, err := getChainedTokenRetriever(settings, credentials)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "workload identity authentication is not enabled in Grafana config")
+		assert.ErrorContains(t, err, "Entra password authentication is not enabled in Grafana config")
+	})
+
+	t.Run("should fail with error if credentials empty", func(t *testing.T) {
+		_, err := getChainedTokenRetriever(settings, &azcredentials.AzureChainedCredentials{})
+		require.Error(t, err)
+	})
+
+	t.Run("should fail with error if user identity credentials chained", func(t *testing.T) {
+		credentials := &azcredentials.AzureChainedCredentials{
+			Credentials: []azcredentials.AzureCredentials{
+				&azcredentials.AadCurrentUserCredentials{},
+			},
+		}
+
+		_, err := getChainedTokenRetriever(settings, credentials)
+		require.Error(t, err)
+	})
+}
+
+func TestChainedTokenRetriever_GetAccessToken(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	failingRetriever := func(key string) *fakeRetriever {
+		return &fakeRetriever{
+			key: key,
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				return nil, errors.New(key + " failed")
+			},
+		}
+	}
+
+	t.Run("should return token of the first retriever which succeeds", func(t *testing.T) {
+		first := failingRetriever("first")
+		second := &fakeRetriever{key: "second"}
+		third := &fakeRetriever{key: "third"}
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: first},
+			{authType: "workloadidentity", retriever: second},
+			{authType: "clientsecret", retriever: third},
+		}}
+
+		err := retriever.Init()
+		require.NoError(t, err)
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "second-token-1", token.Token)
+		assert.Equal(t, 1, first.calledTimes)
+		assert.Equal(t, 1, second.calledTimes)
+		assert.Equal(t, 0, third.calledTimes)
+	})
+
+	t.Run("should try the retriever which succeeded last first", func(t *testing.T) {
+		first := failingRetriever("first")
+		second := &fakeRetriever{key: "second"}
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: first},
+			{authType: "clientsecret", retriever: second},
+		}}
+
+		err := retriever.Init()
+		require.NoError(t, err)
+
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "second-token-2", token.Token)
+		assert.Equal(t, 1, first.calledTimes)
+		assert.Equal(t, 2, second.calledTimes)
+	})
+
+	t.Run("should fall back to other retrievers if the last successful one fails", func(t *testing.T) {
+		firstFails := true
+		first := &fakeRetriever{
+			key: "first",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if firstFails {
+					return nil, errors.New("first failed")
+				}
+				return &AccessToken{Token: "first-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+			},
+		}
+		secondFails := false
+		second := &fakeRetriever{
+			key: "second",
+			getAccessTokenFunc: func(ctx context.Context, scopes []string) (*AccessToken, error) {
+				if secondFails {
+					return nil, errors.New("second failed")
+				}
+				return &AccessToken{Token: "second-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+			},
+		}
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: first},
+			{authType: "clientsecret", retriever: second},
+		}}
+
+		err := retriever.Init()
+		require.NoError(t, err)
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "second-token", token.Token)
+
+		firstFails = false
+		secondFails = true
+
+		token, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "first-token", token.Token)
+	})
+
+	t.Run("should skip retrievers which failed to initialize", func(t *testing.T) {
+		first := &fakeRetriever{
+			key: "first",
+			initFunc: func() error {
+				return errors.New("first init failed")
+			},
+		}
+		second := &fakeRetriever{key: "second"}
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: first},
+			{authType: "clientsecret", retriever: second},
+		}}
+
+		err := retriever.Init()
+		require.NoError(t, err)
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "second-token-1", token.Token)
+		assert.Equal(t, 0, first.calledTimes)
+	})
+
+	t.Run("should fail to initialize if all retrievers fail to initialize", func(t *testing.T) {
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: &fakeRetriever{key: "first", initFunc: func() error { return errors.New("first init failed") }}},
+			{authType: "clientsecret", retriever: &fakeRetriever{key: "second", initFunc: func() error { return errors.New("second init failed") }}},
+		}}
+
+		err := retriever.Init()
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "credentials 0 (msi): first init failed")
+		assert.ErrorContains(t, err, "credentials 1 (clientsecret): second init failed")
+	})
+
+	t.Run("should return errors of all the retrievers if all fail", func(t *testing.T) {
+		retriever := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "workloadidentity", err: errors.New("workload identity authentication is not enabled in Grafana config")},
+			{authType: "msi", retriever: failingRetriever("first")},
+			{authType: "clientsecret", retriever: failingRetriever("second")},
+		}}
+
+		err := retriever.Init()
+		require.NoError(t, err)
+
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "credentials 0 (workloadidentity): workload identity authentication is not enabled in Grafana config")
+		assert.ErrorContains(t, err, "credentials 1 (msi): first failed")
+		assert.ErrorContains(t, err, "credentials 2 (clientsecret): second failed")
+	})
+}
+
+func TestChainedTokenRetriever_GetCacheKey(t *testing.T) {
+	t.Run("should return same key for same chain", func(t *testing.T) {
+		retriever1 := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: &fakeRetriever{key: "first"}},
+			{authType: "clientsecret", retriever: &fakeRetriever{key: "second"}},
+		}}
+		retriever2 := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: &fakeRetriever{key: "first"}},
+			{authType: "clientsecret", retriever: &fakeRetriever{key: "second"}},
+		}}
+
+		assert.Equal(t, retriever1.GetCacheKey("tenant"), retriever2.GetCacheKey("tenant"))
+	})
+
+	t.Run("should return different keys for different order", func(t *testing.T) {
+		retriever1 := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "msi", retriever: &fakeRetriever{key: "first"}},
+			{authType: "clientsecret", retriever: &fakeRetriever{key: "second"}},
+		}}
+		retriever2 := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: "clientsecret", retriever: &fakeRetriever{key: "second"}},
+			{authType: "msi", retriever: &fakeRetriever{key: "first"}},
+		}}
+
+		assert.NotEqual(t, retriever1.GetCacheKey("tenant"), retriever2.GetCacheKey("tenant"))
+	})
+}
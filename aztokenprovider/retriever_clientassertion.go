@@ -0,0 +1,107 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+)
+
+type clientAssertionTokenRetriever struct {
+	cloudConf     cloud.Configuration
+	tenantId      string
+	clientId      string
+	assertionFile string
+	getAssertion  func(ctx context.Context) (string, error)
+	assertionKey  string
+	credential    azcore.TokenCredential
+}
+
+func getClientAssertionTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureClientAssertionCredentials) (TokenRetriever, error) {
+	if credentials.GetAssertion != nil {
+		if credentials.AssertionKey == "" {
+			return nil, fmt.Errorf("client assertion key must be set for client assertion callback")
+		}
+	} else if credentials.AssertionFile == "" {
+		return nil, fmt.Errorf("either client assertion file or callback must be set")
+	} else if !settings.IsClientAssertionFileAllowed(credentials.AssertionFile) {
+		return nil, fmt.Errorf("client assertion file '%s' not allowed in Grafana config", credentials.AssertionFile)
+	}
+
+	// The assertion is only sent to the authority of the cloud
+	authorityHost, err := resolveAuthorityHost(settings, credentials.AzureCloud, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientAssertionTokenRetriever{
+		cloudConf: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: authorityHost,
+			Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+		},
+		tenantId:      credentials.TenantId,
+		clientId:      credentials.ClientId,
+		assertionFile: credentials.AssertionFile,
+		getAssertion:  credentials.GetAssertion,
+		assertionKey:  credentials.AssertionKey,
+	}, nil
+}
+
+func (c *clientAssertionTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
+	return fmt.Sprintf("azure|clientassertion|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.assertionSource()), grafanaMultiTenantId)
+}
+
+// assertionSource identifies the source of the assertion, so that tokens aren't shared between
+// credentials of the same app registration which use different assertions
+func (c *clientAssertionTokenRetriever) assertionSource() string {
+	if c.getAssertion != nil {
+		return "callback:" + c.assertionKey
+	}
+	return "file:" + c.assertionFile
+}
+
+func (c *clientAssertionTokenRetriever) Init() error {
+	getAssertion := c.getAssertion
+	if getAssertion == nil {
+		getAssertion = c.readAssertionFile
+	}
+
+	options := azidentity.ClientAssertionCredentialOptions{}
+	options.Cloud = c.cloudConf
+	if credential, err := azidentity.NewClientAssertionCredential(c.tenantId, c.clientId, getAssertion, &options); err != nil {
+		return err
+	} else {
+		c.credential = credential
+		return nil
+	}
+}
+
+func (c *clientAssertionTokenRetriever) readAssertionFile(_ context.Context) (string, error) {
+	content, err := os.ReadFile(c.assertionFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read client assertion file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (c *clientAssertionTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn}, nil
+}
+
+// Empty implementation
+func (c *clientAssertionTokenRetriever) GetExpiry() *time.Time {
+	return nil
+}
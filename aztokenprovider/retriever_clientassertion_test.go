@@ -0,0 +1,185 @@
+package aztokenprovider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getClientAssertionCredential(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud:                       azsettings.AzurePublic,
+		ClientAssertionAllowedFiles: []string{"/var/run/secrets/tokens/azure-identity-token"},
+	}
+
+	defaultCredentials := func() *azcredentials.AzureClientAssertionCredentials {
+		return &azcredentials.AzureClientAssertionCredentials{
+			AzureCloud:    azsettings.AzurePublic,
+			TenantId:      "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:      "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			AssertionFile: "/var/run/secrets/tokens/azure-identity-token",
+		}
+	}
+
+	t.Run("should return clientAssertionTokenRetriever with values", func(t *testing.T) {
+		credentials := defaultCredentials()
+
+		result, err := getClientAssertionTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &clientAssertionTokenRetriever{}, result)
+		credential := (result).(*clientAssertionTokenRetriever)
+
+		assert.Equal(t, "https://login.microsoftonline.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", credential.tenantId)
+		assert.Equal(t, "1af7c188-e5b6-4f96-81b8-911761bdd459", credential.clientId)
+		assert.Equal(t, "/var/run/secrets/tokens/azure-identity-token", credential.assertionFile)
+	})
+
+	t.Run("authority should be selected based on cloud", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AzureCloud = azsettings.AzureChina
+
+		result, err := getClientAssertionTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		credential := (result).(*clientAssertionTokenRetriever)
+		assert.Equal(t, "https://login.chinacloudapi.cn/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("should fail with error if assertion file not allowed", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AssertionFile = "/etc/passwd"
+
+		_, err := getClientAssertionTokenRetriever(settings, credentials)
+		assert.EqualError(t, err, "client assertion file '/etc/passwd' not allowed in Grafana config")
+	})
+
+	t.Run("should fail with error if assertion callback set without key", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.GetAssertion = func(ctx context.Context) (string, error) {
+			return "FAKE-ASSERTION", nil
+		}
+
+		_, err := getClientAssertionTokenRetriever(settings, credentials)
+		assert.EqualError(t, err, "client assertion key must be set for client assertion callback")
+	})
+
+	t.Run("should fail with error if cloud is not supported", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AzureCloud = "InvalidCloud"
+
+		_, err := getClientAssertionTokenRetriever(settings, credentials)
+		require.Error(t, err)
+	})
+
+	t.Run("should fail with error if neither assertion file nor callback set", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AssertionFile = ""
+
+		_, err := getClientAssertionTokenRetriever(settings, credentials)
+		require.Error(t, err)
+	})
+
+	t.Run("should initialize credential", func(t *testing.T) {
+		result, err := getClientAssertionTokenRetriever(settings, defaultCredentials())
+		require.NoError(t, err)
+
+		err = result.Init()
+		require.NoError(t, err)
+	})
+}
+
+func TestClientAssertionTokenRetriever_GetCacheKey(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud:                       azsettings.AzurePublic,
+		ClientAssertionAllowedFiles: []string{"/var/run/secrets/token1", "/var/run/secrets/token2"},
+	}
+
+	newRetriever := func(assertionFile string) TokenRetriever {
+		retriever, err := getClientAssertionTokenRetriever(settings, &azcredentials.AzureClientAssertionCredentials{
+			AzureCloud:    azsettings.AzurePublic,
+			TenantId:      "TENANT-ID",
+			ClientId:      "CLIENT-ID",
+			AssertionFile: assertionFile,
+		})
+		require.NoError(t, err)
+		return retriever
+	}
+
+	t.Run("should return same key for same assertion file", func(t *testing.T) {
+		key1 := newRetriever("/var/run/secrets/token1").GetCacheKey("")
+		key2 := newRetriever("/var/run/secrets/token1").GetCacheKey("")
+
+		assert.Equal(t, key1, key2)
+	})
+
+	t.Run("should return different keys for different assertion files", func(t *testing.T) {
+		key1 := newRetriever("/var/run/secrets/token1").GetCacheKey("")
+		key2 := newRetriever("/var/run/secrets/token2").GetCacheKey("")
+
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should not expose assertion file in key", func(t *testing.T) {
+		key := newRetriever("/var/run/secrets/token1").GetCacheKey("")
+
+		assert.NotContains(t, key, "/var/run/secrets/token1")
+	})
+
+	newCallbackRetriever := func(assertion string, assertionKey string) TokenRetriever {
+		retriever, err := getClientAssertionTokenRetriever(settings, &azcredentials.AzureClientAssertionCredentials{
+			AzureCloud: azsettings.AzurePublic,
+			TenantId:   "TENANT-ID",
+			ClientId:   "CLIENT-ID",
+			GetAssertion: func(ctx context.Context) (string, error) {
+				return assertion, nil
+			},
+			AssertionKey: assertionKey,
+		})
+		require.NoError(t, err)
+		return retriever
+	}
+
+	t.Run("should return different keys for callbacks with different keys", func(t *testing.T) {
+		// Closures of the same function literal with different captured assertions
+		key1 := newCallbackRetriever("FAKE-ASSERTION-1", "key1").GetCacheKey("")
+		key2 := newCallbackRetriever("FAKE-ASSERTION-2", "key2").GetCacheKey("")
+
+		assert.NotEqual(t, key1, key2)
+	})
+
+	t.Run("should return same key for callbacks with same key", func(t *testing.T) {
+		key1 := newCallbackRetriever("FAKE-ASSERTION", "key1").GetCacheKey("")
+		key2 := newCallbackRetriever("FAKE-ASSERTION", "key1").GetCacheKey("")
+
+		assert.Equal(t, key1, key2)
+	})
+}
+
+func TestClientAssertionTokenRetriever_readAssertionFile(t *testing.T) {
+	t.Run("should read assertion from file", func(t *testing.T) {
+		assertionFile := filepath.Join(t.TempDir(), "token")
+		err := os.WriteFile(assertionFile, []byte("FAKE-ASSERTION\n"), 0600)
+		require.NoError(t, err)
+
+		retriever := &clientAssertionTokenRetriever{assertionFile: assertionFile}
+
+		assertion, err := retriever.readAssertionFile(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-ASSERTION", assertion)
+	})
+
+	t.Run("should fail with error if file doesn't exist", func(t *testing.T) {
+		retriever := &clientAssertionTokenRetriever{assertionFile: filepath.Join(t.TempDir(), "missing")}
+
+		_, err := retriever.readAssertionFile(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -2,9 +2,6 @@ package aztokenprovider
 
 import (
 	"context"
-	"crypto"
-	"crypto/x509"
-	"encoding/base64"
 	"fmt"
 	"time"
 
@@ -14,33 +11,24 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
-	"software.sslmate.com/src/go-pkcs12"
 )
 
 type clientCertificateTokenRetriever struct {
-	cloudConf          cloud.Configuration
-	tenantId           string
-	clientId           string
-	certificateFormat  string
-	clientCertificate  string
-	privateKey         string
-	certificatePassword string
-	credential         azcore.TokenCredential
+	cloudConf            cloud.Configuration
+	tenantId             string
+	clientId             string
+	certificateFormat    string
+	clientCertificate    string
+	privateKey           string
+	certificatePassword  string
+	sendCertificateChain bool
+	credential           azcore.TokenCredential
 }
 
 func getClientCertificateTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureClientCertificateCredentials) (TokenRetriever, error) {
-	var authorityHost string
-
-	if credentials.Authority != "" {
-		// Use AAD authority endpoint configured in credentials
-		authorityHost = credentials.Authority
-	} else {
-		// Resolve cloud settings for the given cloud name
-		cloudSettings, err := settings.GetCloud(credentials.AzureCloud)
-		if err != nil {
-			return nil, err
-		}
-		authorityHost = cloudSettings.AadAuthority
+	authorityHost, err := resolveAuthorityHost(settings, credentials.AzureCloud, credentials.Authority)
+	if err != nil {
+		return nil, err
 	}
 
 	return &clientCertificateTokenRetriever{
@@ -48,52 +36,28 @@ func getClientCertificateTokenRetriever(settings *azsettings.AzureSettings, cred
 			ActiveDirectoryAuthorityHost: authorityHost,
 			Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
 		},
-		tenantId:           credentials.TenantId,
-		clientId:           credentials.ClientId,
-		certificateFormat:  credentials.CertificateFormat,
-		clientCertificate:  credentials.ClientCertificate,
-		privateKey:         credentials.PrivateKey,
-		certificatePassword: credentials.CertificatePassword,
+		tenantId:             credentials.TenantId,
+		clientId:             credentials.ClientId,
+		certificateFormat:    credentials.CertificateFormat,
+		clientCertificate:    credentials.ClientCertificate,
+		privateKey:           credentials.PrivateKey,
+		certificatePassword:  credentials.CertificatePassword,
+		sendCertificateChain: credentials.SendCertificateChain,
 	}, nil
 }
 
 func (c *clientCertificateTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
-	return fmt.Sprintf("azure|clientcertificate|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientCertificate), grafanaMultiTenantId)
+	return fmt.Sprintf("azure|clientcertificate|%s|%s|%s|%s|%t|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientCertificate), c.sendCertificateChain, grafanaMultiTenantId)
 }
 
 func (c *clientCertificateTokenRetriever) Init() error {
-	var joinedKeyCert []byte
-	var certs []*x509.Certificate
-	var key crypto.PrivateKey
-	var err error
-
-	switch c.certificateFormat {
-	case "pem":
-		// Join private key and certificate into a single string as they should be parsed together
-		joinedKeyCert = []byte(c.privateKey + "\n" + c.clientCertificate)
-		certs, key, err = azidentity.ParseCertificates([]byte(joinedKeyCert), []byte(c.certificatePassword))
-	case "pfx":
-		// If we have a password, we need to decode the private key and use the pkcs12 library to parse the certificate and private key
-		// We only accept pfx files that are base64 encoded
-		clientCertificateDecoded, err := base64.StdEncoding.DecodeString(c.clientCertificate)
-		if err != nil {
-			return err
-		}
-		privateKey, cert, caCerts, err := pkcs12.DecodeChain(clientCertificateDecoded, c.certificatePassword)
-		if err != nil {
-			return err
-		}
-		certs = append(certs, cert)
-		certs = append(certs, caCerts...)
-		key = privateKey
-	}
+	certs, key, err := parseClientCertificate(c.certificateFormat, c.clientCertificate, c.privateKey, c.certificatePassword)
 	if err != nil {
 		return err
 	}
 
-	options := azidentity.ClientCertificateCredentialOptions{}
-	options.Cloud = c.cloudConf
-	if credential, err := azidentity.NewClientCertificateCredential(c.tenantId, c.clientId, certs, key, &options); err != nil {
+	options := c.getCredentialOptions()
+	if credential, err := azidentity.NewClientCertificateCredential(c.tenantId, c.clientId, certs, key, options); err != nil {
 		return err
 	} else {
 		c.credential = credential
@@ -101,6 +65,14 @@ func (c *clientCertificateTokenRetriever) Init() error {
 	}
 }
 
+func (c *clientCertificateTokenRetriever) getCredentialOptions() *azidentity.ClientCertificateCredentialOptions {
+	options := &azidentity.ClientCertificateCredentialOptions{}
+	options.Cloud = c.cloudConf
+	// The x5c header with the certificate chain is required for subject name/issuer authentication
+	options.SendCertificateChain = c.sendCertificateChain
+	return options
+}
+
 func (c *clientCertificateTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
 	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
 	if err != nil {
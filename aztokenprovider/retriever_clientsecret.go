@@ -3,52 +3,106 @@ package aztokenprovider
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// Identifies which of the client secrets is used to authenticate
+const (
+	ClientSecretPrimary   = "primary"
+	ClientSecretSecondary = "secondary"
 )
 
 type clientSecretTokenRetriever struct {
-	cloudConf    cloud.Configuration
-	tenantId     string
-	clientId     string
-	clientSecret string
-	credential   azcore.TokenCredential
+	cloudConf             cloud.Configuration
+	tenantId              string
+	clientId              string
+	clientSecret          string
+	clientSecretSecondary string
+	credential            azcore.TokenCredential
+	secondaryCredential   azcore.TokenCredential
+	// Shared by all retrievers of the same credentials with secondary secret, so that the secret in use
+	// is remembered independently of the cache entry
+	state *clientSecretState
 }
 
-func getClientSecretTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureClientSecretCredentials) (TokenRetriever, error) {
-	var authorityHost string
+type clientSecretState struct {
+	usingSecondary atomic.Bool
+	// Fingerprints of the datasource credentials the state is used by
+	fingerprints sync.Map // of struct{}
+}
 
-	if credentials.Authority != "" {
-		// Use AAD authority endpoint configured in credentials
-		authorityHost = credentials.Authority
-	} else {
-		// Resolve cloud settings for the given cloud name
-		cloudSettings, err := settings.GetCloud(credentials.AzureCloud)
-		if err != nil {
-			return nil, err
+var clientSecretStates sync.Map // of *clientSecretState
+
+// trackClientSecretStates associates the client secret states used by the given retriever with the fingerprint
+// of the datasource credentials, so that they are removed when the credentials are evicted
+func trackClientSecretStates(retriever TokenRetriever, fingerprint string) {
+	if fingerprint == "" {
+		return
+	}
+	switch r := retriever.(type) {
+	case *clientSecretTokenRetriever:
+		if r.state != nil {
+			r.state.fingerprints.Store(fingerprint, struct{}{})
+		}
+	case *chainedTokenRetriever:
+		for _, step := range r.steps {
+			trackClientSecretStates(step.retriever, fingerprint)
 		}
-		authorityHost = cloudSettings.AadAuthority
+	}
+}
+
+// evictClientSecretStates removes the client secret states used by the datasource credentials
+// with the given fingerprint
+func evictClientSecretStates(fingerprint string) {
+	clientSecretStates.Range(func(key, state interface{}) bool {
+		if _, ok := state.(*clientSecretState).fingerprints.Load(fingerprint); ok {
+			clientSecretStates.CompareAndDelete(key, state)
+		}
+		return true
+	})
+}
+
+func getClientSecretTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureClientSecretCredentials) (TokenRetriever, error) {
+	authorityHost, err := resolveAuthorityHost(settings, credentials.AzureCloud, credentials.Authority)
+	if err != nil {
+		return nil, err
 	}
 
-	return &clientSecretTokenRetriever{
+	retriever := &clientSecretTokenRetriever{
 		cloudConf: cloud.Configuration{
 			ActiveDirectoryAuthorityHost: authorityHost,
 			Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
 		},
-		tenantId:     credentials.TenantId,
-		clientId:     credentials.ClientId,
-		clientSecret: credentials.ClientSecret,
-	}, nil
+		tenantId:              credentials.TenantId,
+		clientId:              credentials.ClientId,
+		clientSecret:          credentials.ClientSecret,
+		clientSecretSecondary: credentials.ClientSecretSecondary,
+	}
+	if retriever.clientSecretSecondary != "" {
+		state, _ := clientSecretStates.LoadOrStore(retriever.GetCacheKey(""), &clientSecretState{})
+		retriever.state = state.(*clientSecretState)
+	}
+	return retriever, nil
 }
 
 func (c *clientSecretTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
+	if c.clientSecretSecondary != "" {
+		return fmt.Sprintf("azure|clientsecret|%s|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientSecret), hashSecret(c.clientSecretSecondary), grafanaMultiTenantId)
+	}
 	return fmt.Sprintf("azure|clientsecret|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientSecret), grafanaMultiTenantId)
 }
 
@@ -59,12 +113,45 @@ func (c *clientSecretTokenRetriever) Init() error {
 		return err
 	} else {
 		c.credential = credential
-		return nil
 	}
+
+	if c.clientSecretSecondary != "" {
+		if credential, err := azidentity.NewClientSecretCredential(c.tenantId, c.clientId, c.clientSecretSecondary, &options); err != nil {
+			return err
+		} else {
+			c.secondaryCredential = credential
+		}
+	}
+	return nil
 }
 
 func (c *clientSecretTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
-	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	usingSecondary := c.secondaryCredential != nil && c.state.usingSecondary.Load()
+
+	accessToken, err := c.getAccessToken(ctx, scopes, usingSecondary)
+	if err != nil && c.secondaryCredential != nil && isInvalidClientError(err) {
+		// The secret may have been revoked during rotation, try the other secret
+		if otherToken, otherErr := c.getAccessToken(ctx, scopes, !usingSecondary); otherErr == nil {
+			c.state.usingSecondary.Store(!usingSecondary)
+			backend.Logger.Warn("Azure client secret rejected, switched to the other client secret",
+				"clientId", c.clientId, "clientSecret", secretName(!usingSecondary))
+			return otherToken, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return accessToken, nil
+}
+
+func (c *clientSecretTokenRetriever) getAccessToken(ctx context.Context, scopes []string, secondary bool) (*AccessToken, error) {
+	credential := c.credential
+	if secondary {
+		credential = c.secondaryCredential
+	}
+
+	accessToken, err := credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +164,95 @@ func (c *clientSecretTokenRetriever) GetExpiry() *time.Time {
 	return nil
 }
 
+// secretInUse returns which of the client secrets was used to authenticate last
+func (c *clientSecretTokenRetriever) secretInUse() string {
+	return secretName(c.clientSecretSecondary != "" && c.state.usingSecondary.Load())
+}
+
+// GetClientSecretInUse returns whether the primary or the secondary client secret is used by the given provider
+// of client secret credentials to authenticate (ClientSecretPrimary or ClientSecretSecondary), including client
+// secret credentials in chained credentials or fallback service credentials. For chained credentials, the client
+// secret credentials tried first are reported. Returns false if the provider doesn't use client secret credentials.
+func GetClientSecretInUse(provider AzureTokenProvider) (string, bool) {
+	switch p := provider.(type) {
+	case *serviceTokenProvider:
+		return getClientSecretInUse(p.tokenRetriever)
+	case *userTokenProvider:
+		return getClientSecretInUse(p.tokenRetriever)
+	default:
+		return "", false
+	}
+}
+
+func getClientSecretInUse(retriever TokenRetriever) (string, bool) {
+	switch r := retriever.(type) {
+	case *clientSecretTokenRetriever:
+		return r.secretInUse(), true
+	case *chainedTokenRetriever:
+		for _, i := range r.order() {
+			if secretInUse, ok := getClientSecretInUse(r.steps[i].retriever); ok {
+				return secretInUse, true
+			}
+		}
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+func secretName(secondary bool) string {
+	if secondary {
+		return ClientSecretSecondary
+	}
+	return ClientSecretPrimary
+}
+
+// isInvalidClientError returns true if Azure AD rejected the client credentials
+func isInvalidClientError(err error) bool {
+	var authErr *azidentity.AuthenticationFailedError
+	if !errors.As(err, &authErr) || authErr.RawResponse == nil {
+		return false
+	}
+
+	payload, err := runtime.Payload(authErr.RawResponse)
+	if err != nil {
+		return false
+	}
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return false
+	}
+	return response.Error == "invalid_client"
+}
+
+func resolveAuthorityHost(settings *azsettings.AzureSettings, azureCloud string, authority string) (string, error) {
+	if authority != "" {
+		// Use AAD authority endpoint configured in credentials
+		return authority, nil
+	}
+
+	// Resolve cloud settings for the given cloud name
+	cloudSettings, err := settings.GetCloud(azureCloud)
+	if err != nil {
+		return "", err
+	}
+	return cloudSettings.AadAuthority, nil
+}
+
+// getDefaultCloudConfiguration returns the configuration of the cloud where Grafana is hosted
+func getDefaultCloudConfiguration(settings *azsettings.AzureSettings) (cloud.Configuration, error) {
+	authorityHost, err := resolveAuthorityHost(settings, settings.GetDefaultCloud(), "")
+	if err != nil {
+		return cloud.Configuration{}, err
+	}
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: authorityHost,
+		Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+	}, nil
+}
+
 func hashSecret(secret string) string {
 	hash := sha256.New()
 	_, err := hash.Write([]byte(secret))
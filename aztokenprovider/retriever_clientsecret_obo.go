@@ -0,0 +1,55 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+type clientSecretOboTokenRetriever struct {
+	cloudConf    cloud.Configuration
+	tenantId     string
+	clientId     string
+	clientSecret string
+	userId       string
+	idToken      string
+	credential   azcore.TokenCredential
+}
+
+func (c *clientSecretOboTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
+	return fmt.Sprintf("azure|clientsecret-obo|%s|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, hashSecret(c.clientSecret), c.userId, grafanaMultiTenantId)
+}
+
+func (c *clientSecretOboTokenRetriever) Init() error {
+	options := azidentity.OnBehalfOfCredentialOptions{}
+	options.Cloud = c.cloudConf
+	if credential, err := azidentity.NewOnBehalfOfCredentialWithSecret(c.tenantId, c.clientId, c.idToken, c.clientSecret, &options); err != nil {
+		return err
+	} else {
+		c.credential = credential
+		return nil
+	}
+}
+
+func (c *clientSecretOboTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn}, nil
+}
+
+// Returns the expiry time from the ID token or the 0 time value (which will always be expired)
+func (c *clientSecretOboTokenRetriever) GetExpiry() *time.Time {
+	if c != nil {
+		return getIdTokenExpiry(c.idToken)
+	}
+
+	return &time.Time{}
+}
@@ -0,0 +1,71 @@
+package aztokenprovider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSecretOboTokenRetriever(t *testing.T) {
+	defaultRetriever := func() *clientSecretOboTokenRetriever {
+		return &clientSecretOboTokenRetriever{
+			cloudConf: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: "https://login.microsoftonline.com/",
+			},
+			tenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			clientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			clientSecret: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+			userId:       "user1@example.org",
+		}
+	}
+
+	t.Run("cache key should be unique per user", func(t *testing.T) {
+		retriever1 := defaultRetriever()
+		retriever2 := defaultRetriever()
+		retriever2.userId = "user2@example.org"
+
+		assert.NotEqual(t, retriever1.GetCacheKey(""), retriever2.GetCacheKey(""))
+	})
+
+	t.Run("cache key should be unique per app registration", func(t *testing.T) {
+		retriever1 := defaultRetriever()
+		retriever2 := defaultRetriever()
+		retriever2.clientId = "f85aa887-490d-4fac-9306-9b99ad0aa31d"
+
+		assert.NotEqual(t, retriever1.GetCacheKey(""), retriever2.GetCacheKey(""))
+	})
+
+	t.Run("cache key should not contain client secret", func(t *testing.T) {
+		retriever := defaultRetriever()
+
+		assert.NotContains(t, retriever.GetCacheKey(""), retriever.clientSecret)
+	})
+
+	t.Run("returns 0 time value if idToken is empty", func(t *testing.T) {
+		retriever := defaultRetriever()
+		expiry := retriever.GetExpiry()
+
+		require.Equal(t, expiry, &time.Time{})
+	})
+
+	t.Run("returns expiry time of the ID token", func(t *testing.T) {
+		// Truncate to match the library
+		expiryTime := time.Now().Truncate(time.Second)
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+			jwt.MapClaims{
+				"exp": expiryTime.Unix(),
+			})
+		tokenString, err := token.SignedString([]byte("secret-key"))
+		require.NoError(t, err)
+
+		retriever := defaultRetriever()
+		retriever.idToken = tokenString
+		expiry := retriever.GetExpiry()
+
+		require.Equal(t, expiry, &expiryTime)
+	})
+}
@@ -1,7 +1,18 @@
 package aztokenprovider
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
@@ -74,3 +85,218 @@ func TestAzureTokenProvider_getClientSecretCredential(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+type fakeTokenCredential struct {
+	token       string
+	err         error
+	calledTimes int
+}
+
+func (c *fakeTokenCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calledTimes = c.calledTimes + 1
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: c.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func newAuthenticationFailedError(errorCode string) error {
+	return &azidentity.AuthenticationFailedError{
+		RawResponse: &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Status:     "401 Unauthorized",
+			Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"error":"%s","error_description":"AADSTS7000215: Invalid client secret provided."}`, errorCode))),
+		},
+	}
+}
+
+func TestClientSecretTokenRetriever_GetAccessToken(t *testing.T) {
+	ctx := context.Background()
+	scopes := []string{"Scope1"}
+
+	var settings = &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+
+	newRetriever := func(t *testing.T, primary *fakeTokenCredential, secondary *fakeTokenCredential) *clientSecretTokenRetriever {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:     "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			ClientSecret: "FAKE-PRIMARY-SECRET-" + t.Name(),
+		}
+		if secondary != nil {
+			credentials.ClientSecretSecondary = "FAKE-SECONDARY-SECRET-" + t.Name()
+		}
+
+		result, err := getClientSecretTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		retriever := result.(*clientSecretTokenRetriever)
+		retriever.credential = primary
+		if secondary != nil {
+			retriever.secondaryCredential = secondary
+		}
+		return retriever
+	}
+
+	t.Run("should use primary secret", func(t *testing.T) {
+		primary := &fakeTokenCredential{token: "primary-token"}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "primary-token", token.Token)
+		assert.Equal(t, 0, secondary.calledTimes)
+		assert.Equal(t, ClientSecretPrimary, retriever.secretInUse())
+	})
+
+	t.Run("should fall back to secondary secret if primary secret is invalid", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "secondary-token", token.Token)
+		assert.Equal(t, ClientSecretSecondary, retriever.secretInUse())
+	})
+
+	t.Run("should keep using secondary secret once primary secret failed", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+		_, err = retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, primary.calledTimes)
+		assert.Equal(t, 2, secondary.calledTimes)
+	})
+
+	t.Run("should share secret in use between retrievers of same credentials", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		otherRetriever := newRetriever(t, primary, secondary)
+		assert.Equal(t, ClientSecretSecondary, otherRetriever.secretInUse())
+
+		provider := &serviceTokenProvider{tokenRetriever: otherRetriever}
+		secretInUse, ok := GetClientSecretInUse(provider)
+		assert.True(t, ok)
+		assert.Equal(t, ClientSecretSecondary, secretInUse)
+	})
+
+	t.Run("should return secret in use of chained and fallback credentials", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		chained := &chainedTokenRetriever{steps: []*chainedRetrieverStep{
+			{authType: azcredentials.AzureAuthManagedIdentity, err: errors.New("not enabled")},
+			{authType: azcredentials.AzureAuthClientSecret, retriever: retriever},
+		}}
+
+		secretInUse, ok := GetClientSecretInUse(&serviceTokenProvider{tokenRetriever: chained})
+		assert.True(t, ok)
+		assert.Equal(t, ClientSecretSecondary, secretInUse)
+
+		secretInUse, ok = GetClientSecretInUse(&userTokenProvider{tokenRetriever: retriever})
+		assert.True(t, ok)
+		assert.Equal(t, ClientSecretSecondary, secretInUse)
+	})
+
+	t.Run("should not return secret in use of other credentials", func(t *testing.T) {
+		_, ok := GetClientSecretInUse(&serviceTokenProvider{tokenRetriever: &managedIdentityTokenRetriever{}})
+		assert.False(t, ok)
+
+		_, ok = GetClientSecretInUse(&userTokenProvider{})
+		assert.False(t, ok)
+	})
+
+	t.Run("should switch back to primary secret if secondary secret is invalid", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		primary.err = nil
+		primary.token = "primary-token"
+		secondary.err = newAuthenticationFailedError("invalid_client")
+
+		token, err := retriever.GetAccessToken(ctx, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, "primary-token", token.Token)
+		assert.Equal(t, ClientSecretPrimary, retriever.secretInUse())
+	})
+
+	t.Run("should not fall back to secondary secret on other errors", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_scope")}
+		secondary := &fakeTokenCredential{token: "secondary-token"}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+
+		assert.Equal(t, 0, secondary.calledTimes)
+		assert.Equal(t, ClientSecretPrimary, retriever.secretInUse())
+	})
+
+	t.Run("should return error of primary secret if both secrets are invalid", func(t *testing.T) {
+		primaryErr := newAuthenticationFailedError("invalid_client")
+		primary := &fakeTokenCredential{err: primaryErr}
+		secondary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		retriever := newRetriever(t, primary, secondary)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		assert.Equal(t, primaryErr, err)
+		assert.Equal(t, ClientSecretPrimary, retriever.secretInUse())
+	})
+
+	t.Run("should return error if primary secret is invalid and there is no secondary secret", func(t *testing.T) {
+		primary := &fakeTokenCredential{err: newAuthenticationFailedError("invalid_client")}
+		retriever := newRetriever(t, primary, nil)
+
+		_, err := retriever.GetAccessToken(ctx, scopes)
+		require.Error(t, err)
+		assert.Equal(t, ClientSecretPrimary, retriever.secretInUse())
+	})
+}
+
+func TestClientSecretTokenRetriever_GetCacheKey(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+
+	t.Run("should return different keys for different secondary secrets", func(t *testing.T) {
+		credentials := &azcredentials.AzureClientSecretCredentials{
+			AzureCloud:   azsettings.AzurePublic,
+			TenantId:     "TENANT-ID",
+			ClientId:     "CLIENT-ID",
+			ClientSecret: "FAKE-SECRET",
+		}
+		retriever1, err := getClientSecretTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		credentials.ClientSecretSecondary = "FAKE-SECONDARY-SECRET"
+		retriever2, err := getClientSecretTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, retriever1.GetCacheKey(""), retriever2.GetCacheKey(""))
+	})
+}
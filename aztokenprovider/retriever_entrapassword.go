@@ -0,0 +1,85 @@
+package aztokenprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+)
+
+// Tenant used for work and school accounts when no tenant is configured in the credentials
+const entraPasswordDefaultTenant = "organizations"
+
+type entraPasswordTokenRetriever struct {
+	cloudConf  cloud.Configuration
+	tenantId   string
+	clientId   string
+	userId     string
+	password   string
+	credential azcore.TokenCredential
+}
+
+func getEntraPasswordTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureEntraPasswordCredentials) (TokenRetriever, error) {
+	cloudName := credentials.AzureCloud
+	if cloudName == "" {
+		cloudName = settings.GetDefaultCloud()
+	}
+
+	authorityHost, err := resolveAuthorityHost(settings, cloudName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tenantId := credentials.TenantId
+	if tenantId == "" {
+		tenantId = entraPasswordDefaultTenant
+	}
+
+	return &entraPasswordTokenRetriever{
+		cloudConf: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: authorityHost,
+			Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+		},
+		tenantId: tenantId,
+		clientId: credentials.ClientId,
+		userId:   credentials.UserId,
+		password: credentials.Password,
+	}, nil
+}
+
+func (c *entraPasswordTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
+	return fmt.Sprintf("azure|ad-password|%s|%s|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, c.tenantId, c.clientId, c.userId, hashSecret(c.password), grafanaMultiTenantId)
+}
+
+func (c *entraPasswordTokenRetriever) Init() error {
+	// The resource owner password flow is deprecated in azidentity as it doesn't support MFA,
+	// it's only available when explicitly enabled in Grafana config
+	options := azidentity.UsernamePasswordCredentialOptions{}
+	options.Cloud = c.cloudConf
+	if credential, err := azidentity.NewUsernamePasswordCredential(c.tenantId, c.clientId, c.userId, c.password, &options); err != nil {
+		return err
+	} else {
+		c.credential = credential
+		return nil
+	}
+}
+
+func (c *entraPasswordTokenRetriever) GetAccessToken(ctx context.Context, scopes []string) (*AccessToken, error) {
+	accessToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{Token: accessToken.Token, ExpiresOn: accessToken.ExpiresOn}, nil
+}
+
+// Empty implementation
+func (c *entraPasswordTokenRetriever) GetExpiry() *time.Time {
+	return nil
+}
@@ -0,0 +1,100 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getEntraPasswordCredential(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud: azsettings.AzurePublic,
+	}
+
+	defaultCredentials := func() *azcredentials.AzureEntraPasswordCredentials {
+		return &azcredentials.AzureEntraPasswordCredentials{
+			TenantId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			UserId:   "user1@example.org",
+			Password: "0416d95e-8af8-472c-aaa3-15c93c46080a",
+		}
+	}
+
+	t.Run("should return entraPasswordTokenRetriever with values", func(t *testing.T) {
+		credentials := defaultCredentials()
+
+		result, err := getEntraPasswordTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &entraPasswordTokenRetriever{}, result)
+		credential := (result).(*entraPasswordTokenRetriever)
+
+		assert.Equal(t, "https://login.microsoftonline.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", credential.tenantId)
+		assert.Equal(t, "1af7c188-e5b6-4f96-81b8-911761bdd459", credential.clientId)
+		assert.Equal(t, "user1@example.org", credential.userId)
+		assert.Equal(t, "0416d95e-8af8-472c-aaa3-15c93c46080a", credential.password)
+	})
+
+	t.Run("should use organizations tenant if tenant not set", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.TenantId = ""
+
+		result, err := getEntraPasswordTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &entraPasswordTokenRetriever{}, result)
+		credential := (result).(*entraPasswordTokenRetriever)
+
+		assert.Equal(t, "organizations", credential.tenantId)
+	})
+
+	t.Run("authority should be selected based on default cloud if cloud not set", func(t *testing.T) {
+		credentials := defaultCredentials()
+
+		result, err := getEntraPasswordTokenRetriever(&azsettings.AzureSettings{Cloud: azsettings.AzureUSGovernment}, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &entraPasswordTokenRetriever{}, result)
+		credential := (result).(*entraPasswordTokenRetriever)
+
+		assert.Equal(t, "https://login.microsoftonline.us/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("authority should be selected based on cloud", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AzureCloud = azsettings.AzureChina
+
+		result, err := getEntraPasswordTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.IsType(t, &entraPasswordTokenRetriever{}, result)
+		credential := (result).(*entraPasswordTokenRetriever)
+
+		assert.Equal(t, "https://login.chinacloudapi.cn/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("should fail with error if cloud is not supported", func(t *testing.T) {
+		credentials := defaultCredentials()
+		credentials.AzureCloud = "InvalidCloud"
+
+		_, err := getEntraPasswordTokenRetriever(settings, credentials)
+		require.Error(t, err)
+	})
+
+	t.Run("cache key should be unique per user and not contain password", func(t *testing.T) {
+		result1, err := getEntraPasswordTokenRetriever(settings, defaultCredentials())
+		require.NoError(t, err)
+
+		credentials := defaultCredentials()
+		credentials.UserId = "user2@example.org"
+		result2, err := getEntraPasswordTokenRetriever(settings, credentials)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, result1.GetCacheKey(""), result2.GetCacheKey(""))
+		assert.NotContains(t, result1.GetCacheKey(""), "0416d95e-8af8-472c-aaa3-15c93c46080a")
+	})
+}
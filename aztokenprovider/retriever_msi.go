@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
@@ -13,33 +14,83 @@ import (
 )
 
 type managedIdentityTokenRetriever struct {
+	cloudConf  cloud.Configuration
 	clientId   string
+	objectId   string
+	resourceId string
 	credential azcore.TokenCredential
 }
 
-func getManagedIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureManagedIdentityCredentials) TokenRetriever {
-	var clientId string
-	if credentials.ClientId != "" {
-		clientId = credentials.ClientId
-	} else {
-		clientId = settings.ManagedIdentityClientId
+// checkManagedIdentityAllowed returns an error if the datasource selects more than one managed identity or
+// a managed identity which isn't allowed in Grafana config
+func checkManagedIdentityAllowed(settings *azsettings.AzureSettings, credentials *azcredentials.AzureManagedIdentityCredentials) error {
+	key, id, err := azcredentials.GetManagedIdentitySelector(credentials)
+	if err != nil {
+		return err
+	}
+	if !settings.IsManagedIdentityAllowed(id) {
+		switch key {
+		case "objectId":
+			return fmt.Errorf("managed identity with object ID '%s' is not allowed in Grafana config", id)
+		case "resourceId":
+			return fmt.Errorf("managed identity with resource ID '%s' is not allowed in Grafana config", id)
+		default:
+			return fmt.Errorf("managed identity with client ID '%s' is not allowed in Grafana config", id)
+		}
 	}
-	return &managedIdentityTokenRetriever{
-		clientId: clientId,
+	return nil
+}
+
+func getManagedIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureManagedIdentityCredentials) (TokenRetriever, error) {
+	key, id, err := azcredentials.GetManagedIdentitySelector(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Managed identity is always in the same cloud where Grafana is hosted
+	cloudConf, err := getDefaultCloudConfiguration(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	retriever := &managedIdentityTokenRetriever{cloudConf: cloudConf}
+	switch key {
+	case "clientId":
+		retriever.clientId = id
+	case "objectId":
+		retriever.objectId = id
+	case "resourceId":
+		retriever.resourceId = id
+	default:
+		retriever.clientId = settings.ManagedIdentityClientId
 	}
+	return retriever, nil
 }
 
 func (c *managedIdentityTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
-	clientId := c.clientId
-	if clientId == "" {
-		clientId = "system"
+	var identity string
+	switch {
+	case c.objectId != "":
+		identity = "object:" + c.objectId
+	case c.resourceId != "":
+		identity = "resource:" + c.resourceId
+	case c.clientId != "":
+		identity = c.clientId
+	default:
+		identity = "system"
 	}
-	return fmt.Sprintf("azure|msi|%s|%s", clientId, grafanaMultiTenantId)
+	return fmt.Sprintf("azure|msi|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, identity, grafanaMultiTenantId)
 }
 
 func (c *managedIdentityTokenRetriever) Init() error {
 	options := &azidentity.ManagedIdentityCredentialOptions{}
-	if c.clientId != "" {
+	options.Cloud = c.cloudConf
+	switch {
+	case c.objectId != "":
+		options.ID = azidentity.ObjectID(c.objectId)
+	case c.resourceId != "":
+		options.ID = azidentity.ResourceID(c.resourceId)
+	case c.clientId != "":
 		options.ID = azidentity.ClientID(c.clientId)
 	}
 	credential, err := azidentity.NewManagedIdentityCredential(options)
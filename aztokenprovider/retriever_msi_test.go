@@ -0,0 +1,140 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getManagedIdentityCredential(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud:                   azsettings.AzurePublic,
+		ManagedIdentityEnabled:  true,
+		ManagedIdentityClientId: "50dbf8ad-5af9-40b8-ac8e-1a451ee30f6d",
+	}
+
+	t.Run("should use client ID from settings if identity not selected", func(t *testing.T) {
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.IsType(t, &managedIdentityTokenRetriever{}, result)
+		credential := (result).(*managedIdentityTokenRetriever)
+
+		assert.Equal(t, "50dbf8ad-5af9-40b8-ac8e-1a451ee30f6d", credential.clientId)
+		assert.Equal(t, "", credential.objectId)
+		assert.Equal(t, "", credential.resourceId)
+	})
+
+	t.Run("should use system-assigned identity if identity not selected and not set in settings", func(t *testing.T) {
+		result, err := getManagedIdentityTokenRetriever(&azsettings.AzureSettings{}, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "", credential.clientId)
+		assert.Equal(t, "azure|msi|https://login.microsoftonline.com/|system|", credential.GetCacheKey(""))
+	})
+
+	t.Run("should use selected client ID", func(t *testing.T) {
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459"})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "1af7c188-e5b6-4f96-81b8-911761bdd459", credential.clientId)
+		assert.Equal(t, "", credential.objectId)
+		assert.Equal(t, "", credential.resourceId)
+	})
+
+	t.Run("should use selected object ID", func(t *testing.T) {
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{ObjectId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "", credential.clientId)
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", credential.objectId)
+		assert.Equal(t, "", credential.resourceId)
+	})
+
+	t.Run("should use selected resource ID", func(t *testing.T) {
+		resourceId := "/subscriptions/SUBSCRIPTION-ID/resourceGroups/RG/providers/Microsoft.ManagedIdentity/userAssignedIdentities/IDENTITY"
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{ResourceId: resourceId})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "", credential.clientId)
+		assert.Equal(t, "", credential.objectId)
+		assert.Equal(t, resourceId, credential.resourceId)
+	})
+
+	t.Run("authority should be selected based on default cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureUSGovernment}
+
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "https://login.microsoftonline.us/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("authority should be selected from custom cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			Cloud: "CustomCloud",
+			CustomCloudList: []*azsettings.AzureCloudSettings{
+				{
+					Name:         "CustomCloud",
+					AadAuthority: "https://login.contoso.com/",
+				},
+			},
+		}
+
+		result, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		credential := (result).(*managedIdentityTokenRetriever)
+		assert.Equal(t, "https://login.contoso.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("should fail with error if default cloud is not supported", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: "InvalidCloud"}
+
+		_, err := getManagedIdentityTokenRetriever(settings, &azcredentials.AzureManagedIdentityCredentials{})
+		require.Error(t, err)
+	})
+
+	t.Run("should initialize credential with selected identity", func(t *testing.T) {
+		for _, credentials := range []*azcredentials.AzureManagedIdentityCredentials{
+			{},
+			{ClientId: "1af7c188-e5b6-4f96-81b8-911761bdd459"},
+			{ObjectId: "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4"},
+			{ResourceId: "/subscriptions/SUBSCRIPTION-ID/resourceGroups/RG/providers/Microsoft.ManagedIdentity/userAssignedIdentities/IDENTITY"},
+		} {
+			result, err := getManagedIdentityTokenRetriever(settings, credentials)
+			require.NoError(t, err)
+			err = result.Init()
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestManagedIdentityTokenRetriever_GetCacheKey(t *testing.T) {
+	t.Run("should distinguish client ID, object ID and resource ID", func(t *testing.T) {
+		clientKey := (&managedIdentityTokenRetriever{clientId: "IDENTITY"}).GetCacheKey("")
+		objectKey := (&managedIdentityTokenRetriever{objectId: "IDENTITY"}).GetCacheKey("")
+		resourceKey := (&managedIdentityTokenRetriever{resourceId: "IDENTITY"}).GetCacheKey("")
+
+		assert.Equal(t, "azure|msi||IDENTITY|", clientKey)
+		assert.Equal(t, "azure|msi||object:IDENTITY|", objectKey)
+		assert.Equal(t, "azure|msi||resource:IDENTITY|", resourceKey)
+	})
+
+	t.Run("should distinguish clouds", func(t *testing.T) {
+		publicRetriever, err := getManagedIdentityTokenRetriever(&azsettings.AzureSettings{Cloud: azsettings.AzurePublic}, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+		chinaRetriever, err := getManagedIdentityTokenRetriever(&azsettings.AzureSettings{Cloud: azsettings.AzureChina}, &azcredentials.AzureManagedIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, publicRetriever.GetCacheKey(""), chinaRetriever.GetCacheKey(""))
+	})
+}
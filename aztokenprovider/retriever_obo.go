@@ -34,9 +34,18 @@ func (r *onBehalfOfTokenRetriever) GetAccessToken(ctx context.Context, scopes []
 
 // Returns the expiry time from the ID token or the 0 time value (which will always be expired)
 func (c *onBehalfOfTokenRetriever) GetExpiry() *time.Time {
-	if c != nil && c.idToken != "" {
+	if c != nil {
+		return getIdTokenExpiry(c.idToken)
+	}
+
+	return &time.Time{}
+}
+
+// Returns the expiry time from the given ID token or the 0 time value (which will always be expired)
+func getIdTokenExpiry(idToken string) *time.Time {
+	if idToken != "" {
 		claims := jwt.MapClaims{}
-		_, _, err := jwt.NewParser(jwt.WithValidMethods([]string{"ES256"})).ParseUnverified(c.idToken, claims)
+		_, _, err := jwt.NewParser(jwt.WithValidMethods([]string{"ES256"})).ParseUnverified(idToken, claims)
 		if err != nil {
 			// Existing token is invalid in some way so store the new one in cache
 			return &time.Time{}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
@@ -13,13 +14,40 @@ import (
 )
 
 type workloadIdentityTokenRetriever struct {
+	cloudConf  cloud.Configuration
 	tenantId   string
 	clientId   string
 	tokenFile  string
 	credential azcore.TokenCredential
 }
 
-func getWorkloadIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureWorkloadIdentityCredentials) TokenRetriever {
+// checkWorkloadIdentityAllowed returns an error if the datasource selects a client ID which isn't
+// allowed in Grafana config
+func checkWorkloadIdentityAllowed(settings *azsettings.AzureSettings, credentials *azcredentials.AzureWorkloadIdentityCredentials) error {
+	if !settings.IsWorkloadIdentityAllowed(credentials.ClientId) {
+		return fmt.Errorf("workload identity with client ID '%s' is not allowed in Grafana config", credentials.ClientId)
+	}
+	if !settings.IsWorkloadIdentityCloudAllowed(credentials.AzureCloud) {
+		return fmt.Errorf("workload identity in the Azure cloud '%s' is not allowed in Grafana config", credentials.AzureCloud)
+	}
+	return nil
+}
+
+func getWorkloadIdentityTokenRetriever(settings *azsettings.AzureSettings, credentials *azcredentials.AzureWorkloadIdentityCredentials) (TokenRetriever, error) {
+	// Workload identity is in the same cloud where Grafana is hosted unless the cloud of the app registration is set
+	cloudName := settings.GetDefaultCloud()
+	if credentials != nil && credentials.AzureCloud != "" {
+		cloudName = credentials.AzureCloud
+	}
+	authorityHost, err := resolveAuthorityHost(settings, cloudName, "")
+	if err != nil {
+		return nil, err
+	}
+	cloudConf := cloud.Configuration{
+		ActiveDirectoryAuthorityHost: authorityHost,
+		Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+	}
+
 	tenantId := ""
 	clientId := ""
 	tokenFile := ""
@@ -40,10 +68,11 @@ func getWorkloadIdentityTokenRetriever(settings *azsettings.AzureSettings, crede
 	}
 
 	return &workloadIdentityTokenRetriever{
+		cloudConf: cloudConf,
 		tenantId:  tenantId,
 		clientId:  clientId,
 		tokenFile: tokenFile,
-	}
+	}, nil
 }
 
 func (c *workloadIdentityTokenRetriever) GetCacheKey(grafanaMultiTenantId string) string {
@@ -56,11 +85,12 @@ func (c *workloadIdentityTokenRetriever) GetCacheKey(grafanaMultiTenantId string
 		clientId = "default"
 	}
 
-	return fmt.Sprintf("azure|wi|%s|%s|%s", tenantId, clientId, grafanaMultiTenantId)
+	return fmt.Sprintf("azure|wi|%s|%s|%s|%s", c.cloudConf.ActiveDirectoryAuthorityHost, tenantId, clientId, grafanaMultiTenantId)
 }
 
 func (c *workloadIdentityTokenRetriever) Init() error {
 	options := &azidentity.WorkloadIdentityCredentialOptions{}
+	options.Cloud = c.cloudConf
 	if c.tenantId != "" {
 		options.TenantID = c.tenantId
 	}
@@ -0,0 +1,138 @@
+package aztokenprovider
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
+	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureTokenProvider_getWorkloadIdentityCredential(t *testing.T) {
+	var settings = &azsettings.AzureSettings{
+		Cloud:                   azsettings.AzurePublic,
+		WorkloadIdentityEnabled: true,
+		WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+			TenantId:  "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4",
+			ClientId:  "1af7c188-e5b6-4f96-81b8-911761bdd459",
+			TokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+		},
+	}
+
+	t.Run("should return workloadIdentityTokenRetriever with values from settings", func(t *testing.T) {
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.IsType(t, &workloadIdentityTokenRetriever{}, result)
+		credential := (result).(*workloadIdentityTokenRetriever)
+
+		assert.Equal(t, "https://login.microsoftonline.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+		assert.Equal(t, "7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4", credential.tenantId)
+		assert.Equal(t, "1af7c188-e5b6-4f96-81b8-911761bdd459", credential.clientId)
+		assert.Equal(t, "/var/run/secrets/azure/tokens/azure-identity-token", credential.tokenFile)
+	})
+
+	t.Run("should override tenant and client ID from credentials", func(t *testing.T) {
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{
+			TenantId: "TENANT-ID",
+			ClientId: "CLIENT-ID",
+		})
+		require.NoError(t, err)
+
+		credential := (result).(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "TENANT-ID", credential.tenantId)
+		assert.Equal(t, "CLIENT-ID", credential.clientId)
+	})
+
+	t.Run("authority should be selected based on default cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: azsettings.AzureChina}
+
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		credential := (result).(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "https://login.chinacloudapi.cn/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("authority should be selected from custom cloud", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			Cloud: "CustomCloud",
+			CustomCloudList: []*azsettings.AzureCloudSettings{
+				{
+					Name:         "CustomCloud",
+					AadAuthority: "https://login.contoso.com/",
+				},
+			},
+		}
+
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		credential := (result).(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "https://login.contoso.com/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("authority should be selected based on cloud of credentials", func(t *testing.T) {
+		result, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{
+			AzureCloud: azsettings.AzureUSGovernment,
+		})
+		require.NoError(t, err)
+
+		credential := (result).(*workloadIdentityTokenRetriever)
+		assert.Equal(t, "https://login.microsoftonline.us/", credential.cloudConf.ActiveDirectoryAuthorityHost)
+	})
+
+	t.Run("should fail with error if cloud of credentials is not supported", func(t *testing.T) {
+		_, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{
+			AzureCloud: "InvalidCloud",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("should fail with error if default cloud is not supported", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{Cloud: "InvalidCloud"}
+
+		_, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.Error(t, err)
+	})
+
+	t.Run("cache key should include authority", func(t *testing.T) {
+		publicRetriever, err := getWorkloadIdentityTokenRetriever(settings, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+		govRetriever, err := getWorkloadIdentityTokenRetriever(&azsettings.AzureSettings{
+			Cloud:                    azsettings.AzureUSGovernment,
+			WorkloadIdentitySettings: settings.WorkloadIdentitySettings,
+		}, &azcredentials.AzureWorkloadIdentityCredentials{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "azure|wi|https://login.microsoftonline.com/|7dcf1d1a-4ec0-41f2-ac29-c1538a698bc4|1af7c188-e5b6-4f96-81b8-911761bdd459|", publicRetriever.GetCacheKey(""))
+		assert.NotEqual(t, publicRetriever.GetCacheKey(""), govRetriever.GetCacheKey(""))
+	})
+}
+
+func TestCheckWorkloadIdentityAllowed(t *testing.T) {
+	t.Run("should return error if cloud of credentials not allowed", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			Cloud:                    azsettings.AzurePublic,
+			WorkloadIdentityEnabled:  true,
+			WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{},
+		}
+
+		err := checkWorkloadIdentityAllowed(settings, &azcredentials.AzureWorkloadIdentityCredentials{AzureCloud: azsettings.AzureChina})
+		assert.EqualError(t, err, "workload identity in the Azure cloud 'AzureChinaCloud' is not allowed in Grafana config")
+	})
+
+	t.Run("should accept cloud of credentials if cross-cloud enabled", func(t *testing.T) {
+		settings := &azsettings.AzureSettings{
+			Cloud:                   azsettings.AzurePublic,
+			WorkloadIdentityEnabled: true,
+			WorkloadIdentitySettings: &azsettings.WorkloadIdentitySettings{
+				CrossCloudEnabled: true,
+			},
+		}
+
+		err := checkWorkloadIdentityAllowed(settings, &azcredentials.AzureWorkloadIdentityCredentials{AzureCloud: azsettings.AzureChina})
+		assert.NoError(t, err)
+	})
+}
@@ -13,6 +13,10 @@ import (
 
 const grafanaTenantId = "tenantID"
 
+// initRetryDelay is the time after which initialization of the credentials is retried after it failed,
+// requests in the meantime fail with the error of the failed initialization
+const initRetryDelay = 30 * time.Second
+
 var (
 	// timeNow makes it possible to test usage of time
 	timeNow = time.Now
@@ -44,10 +48,16 @@ type tokenCacheImpl struct {
 type credentialCacheEntry struct {
 	retriever TokenRetriever
 	expiry    *time.Time
+	// Fingerprint of the datasource credentials the entry was created for, if known
+	fingerprint string
 
 	credInit  uint32
 	credMutex sync.Mutex
-	cache     sync.Map // of *scopesCacheEntry
+	// Error of the last failed initialization and the time of the failure
+	initErr     error
+	initErrTime time.Time
+
+	cache sync.Map // of *scopesCacheEntry
 }
 
 type scopesCacheEntry struct {
@@ -74,7 +84,8 @@ func (c *tokenCacheImpl) getEntryFor(ctx context.Context, credential TokenRetrie
 	// Store new cache value if there isn't an existing one
 	if !ok {
 		entry, _ = c.cache.LoadOrStore(key, &credentialCacheEntry{
-			retriever: credential,
+			retriever:   credential,
+			fingerprint: getCredentialsFingerprint(ctx),
 		})
 		return entry.(*credentialCacheEntry)
 	}
@@ -83,7 +94,8 @@ func (c *tokenCacheImpl) getEntryFor(ctx context.Context, credential TokenRetrie
 	// Store new cache value if the current one has expired (only applies to OBO retriever)
 	if expiry != nil && !expiry.After(time.Now()) {
 		c.cache.Store(key, &credentialCacheEntry{
-			retriever: credential,
+			retriever:   credential,
+			fingerprint: getCredentialsFingerprint(ctx),
 		})
 		entry, _ = c.cache.Load(key)
 	}
@@ -91,6 +103,19 @@ func (c *tokenCacheImpl) getEntryFor(ctx context.Context, credential TokenRetrie
 	return entry.(*credentialCacheEntry)
 }
 
+// evict removes the entries created for the credentials with the given fingerprint, returns the number
+// of removed entries
+func (c *tokenCacheImpl) evict(fingerprint string) int {
+	evicted := 0
+	c.cache.Range(func(key, entry interface{}) bool {
+		if entry.(*credentialCacheEntry).fingerprint == fingerprint && c.cache.CompareAndDelete(key, entry) {
+			evicted++
+		}
+		return true
+	})
+	return evicted
+}
+
 func (c *credentialCacheEntry) getAccessToken(ctx context.Context, scopes []string) (string, error) {
 	err := c.ensureInitialized()
 	if err != nil {
@@ -106,12 +131,21 @@ func (c *credentialCacheEntry) ensureInitialized() error {
 		defer c.credMutex.Unlock()
 
 		if c.credInit == 0 {
+			// Don't retry failed initialization on each request, the entry is replaced when the credentials
+			// are evicted
+			if c.initErr != nil && timeNow().Before(c.initErrTime.Add(initRetryDelay)) {
+				return c.initErr
+			}
+
 			// Initialize retriever
 			err := c.retriever.Init()
 			if err != nil {
+				c.initErr = err
+				c.initErrTime = timeNow()
 				return err
 			}
 
+			c.initErr = nil
 			atomic.StoreUint32(&c.credInit, 1)
 		}
 	}
@@ -207,6 +241,22 @@ func getKeyForScopes(scopes []string) string {
 	return strings.Join(scopes, " ")
 }
 
+type credentialsFingerprintKey struct{}
+
+// withCredentialsFingerprint returns a context which associates the cache entries created with it
+// with the given fingerprint of the datasource credentials
+func withCredentialsFingerprint(ctx context.Context, fingerprint string) context.Context {
+	if fingerprint == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialsFingerprintKey{}, fingerprint)
+}
+
+func getCredentialsFingerprint(ctx context.Context) string {
+	fingerprint, _ := ctx.Value(credentialsFingerprintKey{}).(string)
+	return fingerprint
+}
+
 func returnGrafanaMultiTenantId(ctx context.Context) (grafanaMultiTenantId string) {
 	md, exists := metadata.FromIncomingContext(ctx)
 
@@ -130,6 +130,40 @@ func TestConcurrentTokenCache_GetAccessToken(t *testing.T) {
 	})
 }
 
+func TestConcurrentTokenCache_Evict(t *testing.T) {
+	scopes := []string{"Scope1"}
+
+	t.Run("should evict only entries of credentials with given fingerprint", func(t *testing.T) {
+		cache := &tokenCacheImpl{}
+		tokenRetriever1 := &fakeRetriever{key: "retriever1"}
+		tokenRetriever2 := &fakeRetriever{key: "retriever2"}
+		tokenRetriever3 := &fakeRetriever{key: "retriever3"}
+
+		_, err := cache.GetAccessToken(withCredentialsFingerprint(context.Background(), "fingerprint1"), tokenRetriever1, scopes)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(withCredentialsFingerprint(context.Background(), "fingerprint2"), tokenRetriever2, scopes)
+		require.NoError(t, err)
+		_, err = cache.GetAccessToken(context.Background(), tokenRetriever3, scopes)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, cache.evict("fingerprint1"))
+		assert.Equal(t, 0, cache.evict("fingerprint1"))
+
+		token, err := cache.GetAccessToken(context.Background(), tokenRetriever1, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "retriever1-token-2", token)
+		assert.Equal(t, 2, tokenRetriever1.initCalledTimes)
+
+		token, err = cache.GetAccessToken(context.Background(), tokenRetriever2, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "retriever2-token-1", token)
+
+		token, err = cache.GetAccessToken(context.Background(), tokenRetriever3, scopes)
+		require.NoError(t, err)
+		assert.Equal(t, "retriever3-token-1", token)
+	})
+}
+
 func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
 	t.Run("when retriever init returns error", func(t *testing.T) {
 		tokenRetriever := &fakeRetriever{
@@ -148,8 +182,10 @@ func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
 			assert.Error(t, err)
 		})
 
-		t.Run("should call init again each time and return error", func(t *testing.T) {
+		t.Run("should call init again each time after retry delay and return error", func(t *testing.T) {
 			tokenRetriever.Reset()
+			now := time.Now()
+			defer setTimeNow(&now)()
 
 			cacheEntry := &credentialCacheEntry{
 				retriever: tokenRetriever,
@@ -159,14 +195,35 @@ func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
 			err = cacheEntry.ensureInitialized()
 			assert.Error(t, err)
 
+			now = now.Add(initRetryDelay)
 			err = cacheEntry.ensureInitialized()
 			assert.Error(t, err)
 
+			now = now.Add(initRetryDelay)
 			err = cacheEntry.ensureInitialized()
 			assert.Error(t, err)
 
 			assert.Equal(t, 3, tokenRetriever.initCalledTimes)
 		})
+
+		t.Run("should return error of failed init without calling init again before retry delay", func(t *testing.T) {
+			tokenRetriever.Reset()
+			now := time.Now()
+			defer setTimeNow(&now)()
+
+			cacheEntry := &credentialCacheEntry{
+				retriever: tokenRetriever,
+			}
+
+			err := cacheEntry.ensureInitialized()
+			assert.EqualError(t, err, "unable to initialize")
+
+			now = now.Add(initRetryDelay - time.Second)
+			err = cacheEntry.ensureInitialized()
+			assert.EqualError(t, err, "unable to initialize")
+
+			assert.Equal(t, 1, tokenRetriever.initCalledTimes)
+		})
 	})
 
 	t.Run("when retriever init returns error only once", func(t *testing.T) {
@@ -182,6 +239,9 @@ func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
 		}
 
 		t.Run("should call retriever init again only while it returns error", func(t *testing.T) {
+			now := time.Now()
+			defer setTimeNow(&now)()
+
 			cacheEntry := &credentialCacheEntry{
 				retriever: tokenRetriever,
 			}
@@ -190,6 +250,7 @@ func TestCredentialCacheEntry_EnsureInitialized(t *testing.T) {
 			err = cacheEntry.ensureInitialized()
 			assert.Error(t, err)
 
+			now = now.Add(initRetryDelay)
 			err = cacheEntry.ensureInitialized()
 			assert.NoError(t, err)
 
@@ -547,3 +608,13 @@ func TestTokenExpiry_GetAccessToken(t *testing.T) {
 	})
 
 }
+
+// setTimeNow makes timeNow return the given time until the returned function restores it
+func setTimeNow(now *time.Time) func() {
+	timeNow = func() time.Time {
+		return *now
+	}
+	return func() {
+		timeNow = time.Now
+	}
+}
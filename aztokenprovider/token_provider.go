@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/v2/azsettings"
@@ -21,7 +23,49 @@ type AzureTokenProvider interface {
 	GetAccessToken(ctx context.Context, scopes []string) (string, error)
 }
 
+// ProviderOptions are the options of the Azure access token provider.
+type ProviderOptions struct {
+	// Whether the datasource supports user identity authentication
+	UserIdentitySupported bool
+
+	// Initialize the credentials (parse certificates, read the configuration and construct the Azure
+	// credential) when the provider is created instead of on the first token request, so that configuration
+	// problems are reported immediately, e.g. when the datasource is saved or on health check.
+	// The credentials are initialized for the Grafana tenant of the context the provider is created with.
+	EagerInit bool
+}
+
 func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials,
+	userIdentitySupported bool) (AzureTokenProvider, error) {
+	return NewAzureAccessTokenProviderWithOptions(context.Background(), settings, credentials, ProviderOptions{
+		UserIdentitySupported: userIdentitySupported,
+	})
+}
+
+// NewAzureAccessTokenProviderWithOptions creates the Azure access token provider for the given credentials.
+func NewAzureAccessTokenProviderWithOptions(ctx context.Context, settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials,
+	options ProviderOptions) (AzureTokenProvider, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return nil, err
+	}
+
+	provider, err := newAzureAccessTokenProvider(settings, credentials, options.UserIdentitySupported)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.EagerInit {
+		if err := initProvider(ctx, provider); err != nil {
+			err = fmt.Errorf("failed to initialize Azure credentials: %w", err)
+			return nil, err
+		}
+	}
+
+	return provider, nil
+}
+
+func newAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials,
 	userIdentitySupported bool) (AzureTokenProvider, error) {
 	var err error
 
@@ -34,55 +78,51 @@ func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials
 		return nil, err
 	}
 
+	if err := checkAuthTypeAvailable(settings, credentials, userIdentitySupported); err != nil {
+		return nil, err
+	}
+
+	// Credentials of custom authentication types may not have a fingerprint, their tokens are cached
+	// but cannot be evicted
+	fingerprint, _ := azcredentials.GetFingerprint(credentials)
+
+	certificates := newCertificateExpiryCheck(settings, credentials)
+	certificates.logWarnings(time.Now())
+
 	switch c := credentials.(type) {
-	case *azcredentials.AzureManagedIdentityCredentials:
-		if !settings.ManagedIdentityEnabled {
-			err = fmt.Errorf("managed identity authentication is not enabled in Grafana config")
-			return nil, err
-		}
-		tokenRetriever := getManagedIdentityTokenRetriever(settings, c)
-		return &serviceTokenProvider{
-			tokenCache:     azureTokenCache,
-			tokenRetriever: tokenRetriever,
-		}, nil
-	case *azcredentials.AzureWorkloadIdentityCredentials:
-		if !settings.WorkloadIdentityEnabled {
-			err = fmt.Errorf("workload identity authentication is not enabled in Grafana config")
-			return nil, err
-		}
-		tokenRetriever := getWorkloadIdentityTokenRetriever(settings, c)
-		return &serviceTokenProvider{
-			tokenCache:     azureTokenCache,
-			tokenRetriever: tokenRetriever,
-		}, nil
-	case *azcredentials.AzureClientSecretCredentials:
-		tokenRetriever, err := getClientSecretTokenRetriever(settings, c)
+	case *azcredentials.AzureManagedIdentityCredentials, *azcredentials.AzureWorkloadIdentityCredentials,
+		*azcredentials.AzureClientSecretCredentials, *azcredentials.AzureClientCertificateCredentials,
+		*azcredentials.AzureClientAssertionCredentials, *azcredentials.AzureEntraPasswordCredentials,
+		*azcredentials.AzureChainedCredentials:
+		tokenRetriever, err := getServiceTokenRetriever(settings, c)
 		if err != nil {
 			return nil, err
 		}
+		trackClientSecretStates(tokenRetriever, fingerprint)
 		return &serviceTokenProvider{
 			tokenCache:     azureTokenCache,
 			tokenRetriever: tokenRetriever,
+			certificates:   certificates,
+			fingerprint:    fingerprint,
 		}, nil
-	case *azcredentials.AzureClientCertificateCredentials:
-		tokenRetriever, err := getClientCertificateTokenRetriever(settings, c)
+	case *azcredentials.AzureClientSecretOboCredentials:
+		serviceCredentials := c.ClientSecretCredentials
+		authorityHost, err := resolveAuthorityHost(settings, serviceCredentials.AzureCloud, serviceCredentials.Authority)
 		if err != nil {
 			return nil, err
 		}
-		return &serviceTokenProvider{
-			tokenCache:     azureTokenCache,
-			tokenRetriever: tokenRetriever,
+		return &oboTokenProvider{
+			tokenCache:  azureTokenCache,
+			fingerprint: fingerprint,
+			cloudConf: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: authorityHost,
+				Services:                     map[cloud.ServiceName]cloud.ServiceConfiguration{},
+			},
+			tenantId:     serviceCredentials.TenantId,
+			clientId:     serviceCredentials.ClientId,
+			clientSecret: serviceCredentials.ClientSecret,
 		}, nil
 	case *azcredentials.AadCurrentUserCredentials:
-		if !userIdentitySupported {
-			err = fmt.Errorf("user identity authentication is not supported by this datasource")
-			return nil, err
-		}
-		if !settings.UserIdentityEnabled {
-			err = fmt.Errorf("user identity authentication is not enabled in Grafana config")
-			return nil, err
-		}
-
 		var tokenRetriever TokenRetriever
 
 		if c.ServiceCredentialsEnabled && c.ServiceCredentials != nil && settings.UserIdentityFallbackCredentialsEnabled {
@@ -90,23 +130,14 @@ func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials
 			if fallbackType == azcredentials.AzureAuthCurrentUserIdentity || fallbackType == azcredentials.AzureAuthClientSecretObo {
 				return nil, fmt.Errorf("user identity authentication not valid for fallback credentials")
 			}
-			switch c.ServiceCredentials.(type) {
-			case *azcredentials.AzureClientSecretCredentials:
-				tokenRetriever, err = getClientSecretTokenRetriever(settings, c.ServiceCredentials.(*azcredentials.AzureClientSecretCredentials))
-				if err != nil {
-					return nil, err
-				}
-			case *azcredentials.AzureClientCertificateCredentials:
-				tokenRetriever, err = getClientCertificateTokenRetriever(settings, c.ServiceCredentials.(*azcredentials.AzureClientCertificateCredentials))
-				if err != nil {
-					return nil, err
-				}
-			case *azcredentials.AzureManagedIdentityCredentials:
-				tokenRetriever = getManagedIdentityTokenRetriever(settings, c.ServiceCredentials.(*azcredentials.AzureManagedIdentityCredentials))
-			case *azcredentials.AzureWorkloadIdentityCredentials:
-				tokenRetriever = getWorkloadIdentityTokenRetriever(settings, c.ServiceCredentials.(*azcredentials.AzureWorkloadIdentityCredentials))
-
+			if azcredentials.IsKeyCredentials(c.ServiceCredentials) {
+				return nil, fmt.Errorf("the authentication type '%s' not valid for fallback credentials", fallbackType)
+			}
+			tokenRetriever, err = getServiceTokenRetriever(settings, c.ServiceCredentials)
+			if err != nil {
+				return nil, err
 			}
+			trackClientSecretStates(tokenRetriever, fingerprint)
 		}
 		tokenEndpoint := settings.UserIdentityTokenEndpoint
 		client, err := NewTokenClient(tokenEndpoint.TokenUrl, tokenEndpoint.ClientAuthentication, tokenEndpoint.ClientId, tokenEndpoint.ClientSecret, tokenEndpoint.ManagedIdentityClientId, tokenEndpoint.FederatedCredentialAudience, http.DefaultClient)
@@ -119,16 +150,116 @@ func NewAzureAccessTokenProvider(settings *azsettings.AzureSettings, credentials
 			client:            client,
 			usernameAssertion: tokenEndpoint.UsernameAssertion,
 			tokenRetriever:    tokenRetriever,
+			certificates:      certificates,
+			fingerprint:       fingerprint,
 		}, nil
+	case *azcredentials.AzureApiKeyCredentials, *azcredentials.AzureStorageSharedKeyCredentials,
+		*azcredentials.AzureStorageSasCredentials:
+		err = fmt.Errorf("credentials of type '%s' cannot be used to retrieve Azure access tokens", c.AzureAuthType())
+		return nil, err
 	default:
 		err = fmt.Errorf("credentials of type '%s' not supported by Azure authentication provider", c.AzureAuthType())
 		return nil, err
 	}
 }
 
+// checkAuthTypeAvailable returns an error if the authentication type of the credentials isn't available
+// as reported by azcredentials.AvailableAuthTypes
+func checkAuthTypeAvailable(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, userIdentitySupported bool) error {
+	// Client assertion callback is set programmatically and doesn't read the assertion file
+	if c, ok := credentials.(*azcredentials.AzureClientAssertionCredentials); ok && c.GetAssertion != nil {
+		return nil
+	}
+	return azcredentials.CheckAuthTypeAvailable(settings, credentials.AzureAuthType(), azcredentials.AuthTypeOptions{
+		UserIdentitySupported: userIdentitySupported,
+	})
+}
+
+// EvictCachedCredentials removes the cached tokens and initialized credentials of the datasource credentials
+// with the given fingerprint (see azcredentials.GetFingerprint) from the token cache, e.g. when the credentials
+// of the datasource were replaced. Returns the number of evicted cache entries.
+func EvictCachedCredentials(fingerprint string) int {
+	if fingerprint == "" {
+		return 0
+	}
+	evictClientSecretStates(fingerprint)
+	if cache, ok := azureTokenCache.(*tokenCacheImpl); ok {
+		return cache.evict(fingerprint)
+	}
+	return 0
+}
+
+// initProvider initializes the token retriever of the service credentials of the given provider in the token
+// cache, so that the initialized retriever is reused by the token requests of the same Grafana tenant
+func initProvider(ctx context.Context, provider AzureTokenProvider) error {
+	switch p := provider.(type) {
+	case *serviceTokenProvider:
+		return initTokenRetriever(withCredentialsFingerprint(ctx, p.fingerprint), p.tokenCache, p.tokenRetriever)
+	case *userTokenProvider:
+		if p.tokenRetriever != nil {
+			return initTokenRetriever(withCredentialsFingerprint(ctx, p.fingerprint), p.tokenCache, p.tokenRetriever)
+		}
+		return nil
+	default:
+		// On-behalf-of retrievers depend on the user and are initialized for each user
+		return nil
+	}
+}
+
+func initTokenRetriever(ctx context.Context, tokenCache ConcurrentTokenCache, tokenRetriever TokenRetriever) error {
+	if cache, ok := tokenCache.(*tokenCacheImpl); ok {
+		return cache.getEntryFor(ctx, tokenRetriever).ensureInitialized()
+	}
+	return tokenRetriever.Init()
+}
+
+// getServiceTokenRetriever returns the retriever for service identity credentials, checking that
+// the authentication type is enabled in Grafana config
+func getServiceTokenRetriever(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials) (TokenRetriever, error) {
+	switch c := credentials.(type) {
+	case *azcredentials.AzureChainedCredentials:
+		// Chained credentials cannot contain chained credentials, see azcredentials.IsChainableCredentials
+		return getChainedTokenRetriever(settings, c)
+	case *azcredentials.AzureManagedIdentityCredentials:
+		if err := checkAuthTypeAvailable(settings, c, false); err != nil {
+			return nil, err
+		}
+		if err := checkManagedIdentityAllowed(settings, c); err != nil {
+			return nil, err
+		}
+		return getManagedIdentityTokenRetriever(settings, c)
+	case *azcredentials.AzureWorkloadIdentityCredentials:
+		if err := checkAuthTypeAvailable(settings, c, false); err != nil {
+			return nil, err
+		}
+		if err := checkWorkloadIdentityAllowed(settings, c); err != nil {
+			return nil, err
+		}
+		return getWorkloadIdentityTokenRetriever(settings, c)
+	case *azcredentials.AzureClientSecretCredentials:
+		return getClientSecretTokenRetriever(settings, c)
+	case *azcredentials.AzureClientCertificateCredentials:
+		return getClientCertificateTokenRetriever(settings, c)
+	case *azcredentials.AzureClientAssertionCredentials:
+		if err := checkAuthTypeAvailable(settings, c, false); err != nil {
+			return nil, err
+		}
+		return getClientAssertionTokenRetriever(settings, c)
+	case *azcredentials.AzureEntraPasswordCredentials:
+		if err := checkAuthTypeAvailable(settings, c, false); err != nil {
+			return nil, err
+		}
+		return getEntraPasswordTokenRetriever(settings, c)
+	default:
+		return nil, fmt.Errorf("credentials of type '%s' not supported by Azure authentication provider", c.AzureAuthType())
+	}
+}
+
 type serviceTokenProvider struct {
 	tokenCache     ConcurrentTokenCache
 	tokenRetriever TokenRetriever
+	certificates   *certificateExpiryCheck
+	fingerprint    string
 }
 
 func (provider *serviceTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
@@ -141,6 +272,7 @@ func (provider *serviceTokenProvider) GetAccessToken(ctx context.Context, scopes
 		return "", err
 	}
 
+	ctx = withCredentialsFingerprint(ctx, provider.fingerprint)
 	accessToken, err := provider.tokenCache.GetAccessToken(ctx, provider.tokenRetriever, scopes)
 	if err != nil {
 		return "", err
@@ -153,6 +285,8 @@ type userTokenProvider struct {
 	client            TokenClient
 	usernameAssertion bool
 	tokenRetriever    TokenRetriever
+	certificates      *certificateExpiryCheck
+	fingerprint       string
 }
 
 func isAzureUser(currentUser azusercontext.CurrentUserContext) (azusercontext.CurrentUserContext, error) {
@@ -214,7 +348,8 @@ func (provider *userTokenProvider) GetAccessToken(ctx context.Context, scopes []
 		// Use fallback credentials if this is a backend request and fallback credentials are enabled
 		if settings.UserIdentityFallbackCredentialsEnabled && !provider.usernameAssertion {
 			if provider.tokenRetriever != nil {
-				accessToken, err := provider.tokenCache.GetAccessToken(ctx, provider.tokenRetriever, scopes)
+				fallbackCtx := withCredentialsFingerprint(ctx, provider.fingerprint)
+				accessToken, err := provider.tokenCache.GetAccessToken(fallbackCtx, provider.tokenRetriever, scopes)
 				if err != nil {
 					return "", err
 				}
@@ -256,6 +391,68 @@ func (provider *userTokenProvider) GetAccessToken(ctx context.Context, scopes []
 		}
 	}
 
+	ctx = withCredentialsFingerprint(ctx, provider.fingerprint)
+	accessToken, err := provider.tokenCache.GetAccessToken(ctx, tokenRetriever, scopes)
+	if err != nil {
+		err = fmt.Errorf("unable to acquire access token for user '%s': %w", username, err)
+		return "", err
+	}
+	return accessToken, nil
+}
+
+// oboTokenProvider exchanges the ID token of the signed-in user for downstream tokens
+// using the app registration configured in the datasource.
+type oboTokenProvider struct {
+	tokenCache   ConcurrentTokenCache
+	cloudConf    cloud.Configuration
+	tenantId     string
+	clientId     string
+	clientSecret string
+	fingerprint  string
+}
+
+func (provider *oboTokenProvider) GetAccessToken(ctx context.Context, scopes []string) (string, error) {
+	if ctx == nil {
+		err := fmt.Errorf("parameter 'ctx' cannot be nil")
+		return "", err
+	}
+	if scopes == nil {
+		err := fmt.Errorf("parameter 'scopes' cannot be nil")
+		return "", err
+	}
+
+	currentUser, ok := azusercontext.GetCurrentUser(ctx)
+	if !ok {
+		return "", fmt.Errorf("user context not configured")
+	}
+
+	azureUser, err := isAzureUser(currentUser)
+	if err != nil {
+		return "", err
+	}
+
+	username, err := extractUsername(azureUser)
+	if err != nil {
+		err := fmt.Errorf("on-behalf-of authentication only possible in context of a Grafana user: %w", err)
+		return "", err
+	}
+
+	idToken := azureUser.IdToken
+	if idToken == "" {
+		err := fmt.Errorf("on-behalf-of authentication not possible because there's no ID token associated with the Grafana user")
+		return "", err
+	}
+
+	tokenRetriever := &clientSecretOboTokenRetriever{
+		cloudConf:    provider.cloudConf,
+		tenantId:     provider.tenantId,
+		clientId:     provider.clientId,
+		clientSecret: provider.clientSecret,
+		userId:       username,
+		idToken:      idToken,
+	}
+
+	ctx = withCredentialsFingerprint(ctx, provider.fingerprint)
 	accessToken, err := provider.tokenCache.GetAccessToken(ctx, tokenRetriever, scopes)
 	if err != nil {
 		err = fmt.Errorf("unable to acquire access token for user '%s': %w", username, err)
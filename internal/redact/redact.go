@@ -0,0 +1,71 @@
+// Package redact formats structs with their secrets replaced by a placeholder, so that they can be safely
+// printed and logged. The formatting methods have value receivers to redact both the structs and pointers to them.
+package redact
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// Placeholder replaces the value of secrets which are set
+const Placeholder = "[REDACTED]"
+
+// Field is a field of a struct formatted with its secrets redacted
+type Field struct {
+	Name  string
+	Value any
+}
+
+// Secret returns the field with the given secret replaced by the placeholder, an empty secret stays empty
+// so that it's visible whether the secret is set
+func Secret(name string, value string) Field {
+	if value != "" {
+		value = Placeholder
+	}
+	return Field{Name: name, Value: value}
+}
+
+// String formats the fields in the same way as the %+v verb formats a struct
+func String(fields ...Field) string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		_, _ = fmt.Fprintf(&sb, "%s:%+v", field.Name, field.Value)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// GoString formats the fields in the same way as the %#v verb formats a struct of the given type
+func GoString(typeName string, fields ...Field) string {
+	var sb strings.Builder
+	sb.WriteString(typeName)
+	sb.WriteString("{")
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&sb, "%s:%#v", field.Name, field.Value)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// LogValue returns the fields as a group, values implementing slog.LogValuer are resolved by the handler
+func LogValue(fields ...Field) slog.Value {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		value := field.Value
+		// LogValue of a nil pointer would panic when resolved by the handler
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+			value = nil
+		}
+		attrs = append(attrs, slog.Any(field.Name, value))
+	}
+	return slog.GroupValue(attrs...)
+}